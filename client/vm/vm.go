@@ -82,6 +82,31 @@ func Status(json string, host string) (string, error) {
 	return string(b), nil
 }
 
+// Resume will resume a suspended virtual machine.
+func Resume(json string, host string) (string, error) {
+	path := fmt.Sprintf("http://%s/v1/vm/resume", host)
+	payload := bytes.NewBuffer([]byte(json))
+	client := http.Client{}
+
+	req, err := http.NewRequest(http.MethodPost, path, payload)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Println("error reading response: ", err.Error())
+		return "", err
+	}
+
+	return string(b), nil
+}
+
 // Stop will stop a virtual machine.
 func Stop(json string, host string) (string, error) {
 	path := fmt.Sprintf("http://%s/v1/vm/stop", host)
@@ -106,3 +131,28 @@ func Stop(json string, host string) (string, error) {
 
 	return string(b), nil
 }
+
+// Suspend will suspend a virtual machine.
+func Suspend(json string, host string) (string, error) {
+	path := fmt.Sprintf("http://%s/v1/vm/suspend", host)
+	payload := bytes.NewBuffer([]byte(json))
+	client := http.Client{}
+
+	req, err := http.NewRequest(http.MethodPost, path, payload)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Println("error reading response: ", err.Error())
+		return "", err
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,116 @@
+// Package cloudsql implements functions to manage the state of Cloud SQL instances.
+package cloudsql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sqladmin "google.golang.org/api/sqladmin/v1"
+)
+
+// Activation policies of a Cloud SQL instance.
+const (
+	Always = "ALWAYS"
+	Never  = "NEVER"
+)
+
+// Instance stores details of a Cloud SQL instance.
+type Instance struct {
+	Name             string `json:"name"`
+	State            string `json:"state"`
+	ActivationPolicy string `json:"activation_policy"`
+}
+
+// Instances stores Cloud SQL instances in specific project.
+type Instances struct {
+	Project string
+}
+
+// NewInstances returns an Instances struct with provided project.
+func NewInstances(project string) *Instances {
+	return &Instances{
+		Project: project,
+	}
+}
+
+// Get returns the details of a Cloud SQL instance.
+func (i *Instances) Get(ctx context.Context, name string) (Instance, error) {
+	svc, err := sqladmin.NewService(ctx)
+	if err != nil {
+		return Instance{}, fmt.Errorf("new sqladmin service: %w", err)
+	}
+
+	inst, err := svc.Instances.Get(i.Project, name).Context(ctx).Do()
+	if err != nil {
+		return Instance{}, fmt.Errorf("get sql instance %q: %w", name, err)
+	}
+
+	var policy string
+	if inst.Settings != nil {
+		policy = inst.Settings.ActivationPolicy
+	}
+
+	return Instance{
+		Name:             inst.Name,
+		State:            inst.State,
+		ActivationPolicy: policy,
+	}, nil
+}
+
+// Start will set the activation policy of a Cloud SQL instance to ALWAYS.
+func (i *Instances) Start(ctx context.Context, name string) error {
+	return i.setActivationPolicy(ctx, name, Always)
+}
+
+// Stop will set the activation policy of a Cloud SQL instance to NEVER.
+func (i *Instances) Stop(ctx context.Context, name string) error {
+	return i.setActivationPolicy(ctx, name, Never)
+}
+
+// setActivationPolicy updates the activation policy of a Cloud SQL instance and waits for the operation.
+func (i *Instances) setActivationPolicy(ctx context.Context, name string, policy string) error {
+	svc, err := sqladmin.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("new sqladmin service: %w", err)
+	}
+
+	patch := &sqladmin.DatabaseInstance{
+		Settings: &sqladmin.Settings{
+			ActivationPolicy: policy,
+		},
+	}
+
+	op, err := svc.Instances.Patch(i.Project, name, patch).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("patch sql instance %q: %w", name, err)
+	}
+
+	return i.wait(ctx, svc, op.Name)
+}
+
+// wait waits until the operation is done, or the context is done.
+func (i *Instances) wait(ctx context.Context, svc *sqladmin.Service, name string) error {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		op, err := svc.Operations.Get(i.Project, name).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("wait operation: %w", err)
+		}
+
+		if op.Status == "DONE" {
+			if op.Error != nil && len(op.Error.Errors) > 0 {
+				return fmt.Errorf("operation %q: %s", name, op.Error.Errors[0].Message)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait operation %q: %w", name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Package compute selects the compute provider of a group or a command.
+package compute
+
+import (
+	"fmt"
+
+	"github.com/marintailor/rcstate/cmd/api/ec2"
+	"github.com/marintailor/rcstate/cmd/api/gce"
+	"github.com/marintailor/rcstate/cmd/api/libvirt"
+	"github.com/marintailor/rcstate/cmd/api/provider"
+)
+
+// NewProvider returns the compute provider selected by name in the options.
+// Google Compute Engine is used when no provider name is set.
+func NewProvider(o provider.Options) (provider.Provider, error) {
+	switch o.Name {
+	case "", provider.GCE:
+		return gce.NewInstances(o.Project, o.Zone), nil
+	case provider.AWS:
+		if o.Region == "" {
+			return nil, fmt.Errorf("provider %q requires a region", o.Name)
+		}
+		return ec2.NewInstances(o.Region, o.Endpoint), nil
+	case provider.Libvirt:
+		return libvirt.NewDomains(o.Endpoint), nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q", o.Name)
+	}
+}
@@ -0,0 +1,156 @@
+// Package container implements functions to manage local containers and compose projects.
+package container
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// DefaultRuntime is the container runtime used when no runtime is provided.
+const DefaultRuntime = "docker"
+
+// Container stores details of a container or a compose project.
+type Container struct {
+	Name    string `json:"name"`
+	Compose bool   `json:"compose"`
+	Status  string `json:"status"`
+}
+
+// Runtime is a container runtime CLI, like docker or podman.
+type Runtime struct {
+	Name string
+}
+
+// NewRuntime returns a Runtime struct with provided runtime name.
+func NewRuntime(name string) (*Runtime, error) {
+	switch name {
+	case "":
+		name = DefaultRuntime
+	case "docker", "podman":
+	default:
+		return nil, fmt.Errorf("unknown container runtime %q", name)
+	}
+
+	return &Runtime{
+		Name: name,
+	}, nil
+}
+
+// run runs a runtime command and returns its output.
+func (r *Runtime) run(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, r.Name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s %s: %w: %s", r.Name, args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Start will start a container, or all containers of a compose project.
+func (r *Runtime) Start(ctx context.Context, name string, compose bool) error {
+	args := []string{"start", name}
+	if compose {
+		args = []string{"compose", "--project-name", name, "start"}
+	}
+
+	if _, err := r.run(ctx, args...); err != nil {
+		return fmt.Errorf("start container: %w", err)
+	}
+
+	return nil
+}
+
+// Stop will stop a container, or all containers of a compose project.
+func (r *Runtime) Stop(ctx context.Context, name string, compose bool) error {
+	args := []string{"stop", name}
+	if compose {
+		args = []string{"compose", "--project-name", name, "stop"}
+	}
+
+	if _, err := r.run(ctx, args...); err != nil {
+		return fmt.Errorf("stop container: %w", err)
+	}
+
+	return nil
+}
+
+// Status returns the status of a container, or a summary of containers' state of a compose project.
+func (r *Runtime) Status(ctx context.Context, name string, compose bool) (string, error) {
+	if !compose {
+		out, err := r.run(ctx, "inspect", "--format", "{{.State.Status}}", name)
+		if err != nil {
+			return "", fmt.Errorf("get status container %q: %w", name, err)
+		}
+
+		return strings.ToUpper(out), nil
+	}
+
+	out, err := r.run(ctx, "ps", "--all", "--filter", "label="+r.projectLabel()+"="+name, "--format", "{{.State}}")
+	if err != nil {
+		return "", fmt.Errorf("get status compose project %q: %w", name, err)
+	}
+
+	return composeStatus(strings.Fields(out)), nil
+}
+
+// projectLabel returns the label that the compose tool of the runtime sets on the containers of a project.
+func (r *Runtime) projectLabel() string {
+	if r.Name == "podman" {
+		return "io.podman.compose.project"
+	}
+
+	return "com.docker.compose.project"
+}
+
+// composeStatus returns the status of a compose project based on the state of its containers.
+func composeStatus(states []string) string {
+	var running int
+	for _, s := range states {
+		if s == "running" {
+			running++
+		}
+	}
+
+	switch {
+	case len(states) == 0:
+		return "NOT_FOUND"
+	case running == len(states):
+		return "RUNNING"
+	case running == 0:
+		return "EXITED"
+	default:
+		return fmt.Sprintf("PARTIAL (%d/%d)", running, len(states))
+	}
+}
+
+// Exec executes a shell command inside a container, or inside a service of a compose project,
+// and writes its output to out.
+func (r *Runtime) Exec(ctx context.Context, name string, compose bool, service string, cmd string, out io.Writer) error {
+	args := []string{"exec", name, "sh", "-c", cmd}
+	if compose {
+		if service == "" {
+			return fmt.Errorf("exec in compose project %q: service is not provided", name)
+		}
+		args = []string{"compose", "--project-name", name, "exec", "-T", service, "sh", "-c", cmd}
+	}
+
+	output, err := r.run(ctx, args...)
+	if err != nil {
+		return fmt.Errorf("exec cmd: %w", err)
+	}
+
+	if output != "" {
+		fmt.Fprintln(out, output)
+	}
+
+	return nil
+}
@@ -0,0 +1,281 @@
+// Package ec2 implements the compute provider for Amazon EC2 instances.
+package ec2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/marintailor/rcstate/cmd/api/provider"
+)
+
+// Instances stores list of instances in specific region.
+// It implements the provider.Provider interface for Amazon EC2.
+type Instances struct {
+	List     []provider.Instance
+	Endpoint string
+	Region   string
+}
+
+// NewInstances returns an Instances struct with provided region and optional API endpoint.
+func NewInstances(region string, endpoint string) *Instances {
+	return &Instances{
+		Endpoint: endpoint,
+		Region:   region,
+	}
+}
+
+// client returns an EC2 API client for the region and endpoint.
+func (i *Instances) client() (*ec2.EC2, error) {
+	conf := aws.NewConfig().WithRegion(i.Region)
+	if i.Endpoint != "" {
+		conf = conf.WithEndpoint(i.Endpoint)
+	}
+
+	sess, err := session.NewSession(conf)
+	if err != nil {
+		return nil, fmt.Errorf("new session: %w", err)
+	}
+
+	return ec2.New(sess), nil
+}
+
+// GetList returns a slice of Instance.
+func (i *Instances) GetList(ctx context.Context) ([]provider.Instance, error) {
+	svc, err := i.client()
+	if err != nil {
+		return []provider.Instance{}, err
+	}
+
+	i.List = nil
+
+	err = svc.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{}, func(out *ec2.DescribeInstancesOutput, last bool) bool {
+		for _, r := range out.Reservations {
+			for _, inst := range r.Instances {
+				i.List = append(i.List, getInstanceDetails(inst))
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return []provider.Instance{}, fmt.Errorf("describe instances: %w", err)
+	}
+
+	return i.List, nil
+}
+
+// getInstanceDetails returns a Instance struct with instance's details.
+func getInstanceDetails(inst *ec2.Instance) provider.Instance {
+	var zone string
+	if inst.Placement != nil {
+		zone = aws.StringValue(inst.Placement.AvailabilityZone)
+	}
+
+	labels := map[string]string{}
+	for _, t := range inst.Tags {
+		labels[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+
+	return provider.Instance{
+		ID:          aws.StringValue(inst.InstanceId),
+		Name:        getInstanceName(inst),
+		Status:      strings.ToUpper(aws.StringValue(inst.State.Name)),
+		Internal:    aws.StringValue(inst.PrivateIpAddress),
+		External:    aws.StringValue(inst.PublicIpAddress),
+		Type:        aws.StringValue(inst.InstanceType),
+		Preemptible: aws.StringValue(inst.InstanceLifecycle) == ec2.InstanceLifecycleTypeSpot,
+		Zone:        zone,
+		Labels:      labels,
+	}
+}
+
+// getInstanceName returns the value of the Name tag, or the instance ID if the tag is missing.
+func getInstanceName(inst *ec2.Instance) string {
+	for _, t := range inst.Tags {
+		if aws.StringValue(t.Key) == "Name" {
+			return aws.StringValue(t.Value)
+		}
+	}
+
+	return aws.StringValue(inst.InstanceId)
+}
+
+// describeInput returns the input to describe an instance by instance ID or by Name tag.
+func describeInput(inst string) *ec2.DescribeInstancesInput {
+	if strings.HasPrefix(inst, "i-") {
+		return &ec2.DescribeInstancesInput{
+			InstanceIds: []*string{aws.String(inst)},
+		}
+	}
+
+	return &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("tag:Name"),
+				Values: []*string{aws.String(inst)},
+			},
+		},
+	}
+}
+
+// describe returns the details of an instance identified by instance ID or Name tag.
+func describe(ctx context.Context, svc *ec2.EC2, inst string) (*ec2.Instance, error) {
+	out, err := svc.DescribeInstancesWithContext(ctx, describeInput(inst))
+	if err != nil {
+		return nil, fmt.Errorf("describe instance %q: %w", inst, err)
+	}
+
+	var found []*ec2.Instance
+	for _, r := range out.Reservations {
+		for _, i := range r.Instances {
+			if aws.StringValue(i.State.Name) != ec2.InstanceStateNameTerminated {
+				found = append(found, i)
+			}
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("instance %q not found", inst)
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("instance name %q matches %d instances", inst, len(found))
+	}
+}
+
+// Start will start an instance.
+func (i *Instances) Start(ctx context.Context, inst string) error {
+	svc, err := i.client()
+	if err != nil {
+		return err
+	}
+
+	d, err := describe(ctx, svc, inst)
+	if err != nil {
+		return fmt.Errorf("start instance: %w", err)
+	}
+
+	input := &ec2.StartInstancesInput{
+		InstanceIds: []*string{d.InstanceId},
+	}
+
+	if _, err := svc.StartInstancesWithContext(ctx, input); err != nil {
+		return fmt.Errorf("start instance: %w", err)
+	}
+
+	waitInput := &ec2.DescribeInstancesInput{
+		InstanceIds: []*string{d.InstanceId},
+	}
+
+	if err := svc.WaitUntilInstanceRunningWithContext(ctx, waitInput); err != nil {
+		return fmt.Errorf("wait operation: %w", err)
+	}
+
+	return nil
+}
+
+// Status returns the status of the instance.
+func (i *Instances) Status(ctx context.Context, inst string) (string, error) {
+	svc, err := i.client()
+	if err != nil {
+		return "", err
+	}
+
+	d, err := describe(ctx, svc, inst)
+	if err != nil {
+		return "", fmt.Errorf("get status instance %q: %w", inst, err)
+	}
+
+	return strings.ToUpper(aws.StringValue(d.State.Name)), nil
+}
+
+// Stop will stop the instance.
+func (i *Instances) Stop(ctx context.Context, inst string) error {
+	svc, err := i.client()
+	if err != nil {
+		return err
+	}
+
+	d, err := describe(ctx, svc, inst)
+	if err != nil {
+		return fmt.Errorf("stop instance: %w", err)
+	}
+
+	input := &ec2.StopInstancesInput{
+		InstanceIds: []*string{d.InstanceId},
+	}
+
+	if _, err := svc.StopInstancesWithContext(ctx, input); err != nil {
+		return fmt.Errorf("stop instance: %w", err)
+	}
+
+	waitInput := &ec2.DescribeInstancesInput{
+		InstanceIds: []*string{d.InstanceId},
+	}
+
+	if err := svc.WaitUntilInstanceStoppedWithContext(ctx, waitInput); err != nil {
+		return fmt.Errorf("wait operation: %w", err)
+	}
+
+	return nil
+}
+
+// MachineType returns the instance type of the instance.
+func (i *Instances) MachineType(ctx context.Context, inst string) (string, error) {
+	svc, err := i.client()
+	if err != nil {
+		return "", err
+	}
+
+	d, err := describe(ctx, svc, inst)
+	if err != nil {
+		return "", fmt.Errorf("machine type: %w", err)
+	}
+
+	return aws.StringValue(d.InstanceType), nil
+}
+
+// SetMachineType changes the instance type of a stopped instance.
+func (i *Instances) SetMachineType(ctx context.Context, inst string, machineType string) error {
+	svc, err := i.client()
+	if err != nil {
+		return err
+	}
+
+	d, err := describe(ctx, svc, inst)
+	if err != nil {
+		return fmt.Errorf("set machine type: %w", err)
+	}
+
+	input := &ec2.ModifyInstanceAttributeInput{
+		InstanceId: d.InstanceId,
+		InstanceType: &ec2.AttributeValue{
+			Value: aws.String(machineType),
+		},
+	}
+
+	if _, err := svc.ModifyInstanceAttributeWithContext(ctx, input); err != nil {
+		return fmt.Errorf("set machine type: %w", err)
+	}
+
+	return nil
+}
+
+// ExternalIP returns the public IP address of the instance.
+func (i *Instances) ExternalIP(ctx context.Context, inst string) (string, error) {
+	svc, err := i.client()
+	if err != nil {
+		return "", err
+	}
+
+	d, err := describe(ctx, svc, inst)
+	if err != nil {
+		return "", fmt.Errorf("external ip: %w", err)
+	}
+
+	return aws.StringValue(d.PublicIpAddress), nil
+}
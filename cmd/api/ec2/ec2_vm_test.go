@@ -0,0 +1,186 @@
+package ec2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ec2Instance is an instance of the EC2 stand-in.
+type ec2Instance struct {
+	id    string
+	name  string
+	state string
+}
+
+// ec2Stub is a local stand-in of the EC2 API, answering the query API actions used by Instances.
+// Started and stopped instances reach their state at once, so the waiters succeed on their first attempt.
+type ec2Stub struct {
+	mu        sync.Mutex
+	actions   []string
+	instances []*ec2Instance
+}
+
+func newEC2Stub(t *testing.T, instances ...*ec2Instance) (*ec2Stub, *Instances) {
+	t.Helper()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+
+	s := &ec2Stub{instances: instances}
+
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	return s, NewInstances("us-east-1", srv.URL)
+}
+
+func (s *ec2Stub) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := req.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	action := req.PostForm.Get("Action")
+	w.Header().Set("Content-Type", "text/xml")
+
+	switch action {
+	case "DescribeInstances":
+		fmt.Fprintf(w, "<DescribeInstancesResponse><requestId>1</requestId><reservationSet>%s</reservationSet></DescribeInstancesResponse>", s.describe(req))
+	case "StartInstances", "StopInstances":
+		id := req.PostForm.Get("InstanceId.1")
+		s.actions = append(s.actions, action+" "+id)
+
+		for _, inst := range s.instances {
+			if inst.id != id {
+				continue
+			}
+
+			if action == "StartInstances" {
+				inst.state = "running"
+			} else {
+				inst.state = "stopped"
+			}
+		}
+
+		fmt.Fprintf(w, "<%sResponse><requestId>1</requestId></%sResponse>", action, action)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "<Response><Errors><Error><Code>InvalidAction</Code><Message>%s</Message></Error></Errors></Response>", action)
+	}
+}
+
+// describe returns the reservations of the instances matching the instance ID or Name tag filter of the request.
+func (s *ec2Stub) describe(req *http.Request) string {
+	id := req.PostForm.Get("InstanceId.1")
+
+	var name string
+	if req.PostForm.Get("Filter.1.Name") == "tag:Name" {
+		name = req.PostForm.Get("Filter.1.Value.1")
+	}
+
+	var b strings.Builder
+
+	for _, inst := range s.instances {
+		if (id != "" && inst.id != id) || (name != "" && inst.name != name) {
+			continue
+		}
+
+		var tags string
+		if inst.name != "" {
+			tags = fmt.Sprintf("<item><key>Name</key><value>%s</value></item>", inst.name)
+		}
+
+		fmt.Fprintf(&b, "<item><reservationId>r-%s</reservationId><instancesSet><item>"+
+			"<instanceId>%s</instanceId><instanceType>t3.micro</instanceType>"+
+			"<instanceState><name>%s</name></instanceState>"+
+			"<placement><availabilityZone>us-east-1a</availabilityZone></placement>"+
+			"<tagSet>%s</tagSet>"+
+			"</item></instancesSet></item>", inst.id, inst.id, inst.state, tags)
+	}
+
+	return b.String()
+}
+
+func TestInstances(t *testing.T) {
+	s, i := newEC2Stub(t,
+		&ec2Instance{id: "i-0abc", name: "web", state: "stopped"},
+		&ec2Instance{id: "i-0def", state: "running"},
+		&ec2Instance{id: "i-0old", name: "old", state: "terminated"},
+	)
+	ctx := context.Background()
+
+	list, err := i.GetList(ctx)
+	if err != nil {
+		t.Fatalf("get list: %s", err)
+	}
+
+	var got []string
+	for _, inst := range list {
+		got = append(got, fmt.Sprintf("%s %s %s %s", inst.ID, inst.Name, inst.Status, inst.Zone))
+	}
+
+	want := []string{"i-0abc web STOPPED us-east-1a", "i-0def i-0def RUNNING us-east-1a", "i-0old old TERMINATED us-east-1a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("list = %v, want %v", got, want)
+	}
+
+	status := func(inst string) string {
+		t.Helper()
+
+		st, err := i.Status(ctx, inst)
+		if err != nil {
+			t.Fatalf("status: %s", err)
+		}
+
+		return st
+	}
+
+	if got := status("web"); got != "STOPPED" {
+		t.Errorf("status by name = %s, want STOPPED", got)
+	}
+
+	if err := i.Start(ctx, "web"); err != nil {
+		t.Fatalf("start: %s", err)
+	}
+
+	if got := status("i-0abc"); got != "RUNNING" {
+		t.Errorf("status by ID after start = %s, want RUNNING", got)
+	}
+
+	if err := i.Stop(ctx, "i-0abc"); err != nil {
+		t.Fatalf("stop: %s", err)
+	}
+
+	if got := status("web"); got != "STOPPED" {
+		t.Errorf("status by name after stop = %s, want STOPPED", got)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if want := []string{"StartInstances i-0abc", "StopInstances i-0abc"}; !reflect.DeepEqual(s.actions, want) {
+		t.Errorf("actions = %v, want %v", s.actions, want)
+	}
+}
+
+func TestInstancesNotFound(t *testing.T) {
+	_, i := newEC2Stub(t, &ec2Instance{id: "i-0old", name: "old", state: "terminated"})
+	ctx := context.Background()
+
+	for _, inst := range []string{"old", "missing", "i-0missing"} {
+		if err := i.Start(ctx, inst); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("start %q error = %v, want not found", inst, err)
+		}
+	}
+}
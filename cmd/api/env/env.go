@@ -2,14 +2,20 @@ package env
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"html/template"
+	"io"
 	"os"
 	"strings"
+	"time"
 
-	"github.com/marintailor/rcstate/cmd/api/gce"
+	"github.com/marintailor/rcstate/cmd/api/compute"
+	"github.com/marintailor/rcstate/cmd/api/flagutil"
+	"github.com/marintailor/rcstate/cmd/api/provider"
 	"github.com/marintailor/rcstate/cmd/api/record"
 	"github.com/marintailor/rcstate/cmd/api/ssh"
 	"gopkg.in/yaml.v2"
@@ -26,37 +32,143 @@ type Variable map[string]interface{}
 
 // Environment stores details of an environment.
 type Environment struct {
-	Group []Group `yaml:"group"`
-	Label string  `yaml:"label"`
-	Name  string  `yaml:"name"`
+	Group     []Group `yaml:"group"`
+	Label     string  `yaml:"label"`
+	Name      string  `yaml:"name"`
+	OnFailure string  `yaml:"on_failure"`
+	Timeout   Timeout `yaml:"timeout"`
+}
+
+// Timeout stores the maximum duration of each kind of operation, in format of time.ParseDuration.
+type Timeout struct {
+	DNS      string `yaml:"dns"`
+	Script   string `yaml:"script"`
+	Snapshot string `yaml:"snapshot"`
+	Start    string `yaml:"start"`
+	Stop     string `yaml:"stop"`
 }
 
 // Group stores details of a group.
 type Group struct {
-	Name     string   `yaml:"name"`
-	Project  string   `yaml:"project"`
-	Resource Resource `yaml:"resource"`
-	Zone     string   `yaml:"zone"`
+	DependsOn         []string `yaml:"depends_on"`
+	DownMode          string   `yaml:"down_mode"`
+	Endpoint          string   `yaml:"endpoint"`
+	Name              string   `yaml:"name"`
+	Parallel          string   `yaml:"parallel"`
+	Project           string   `yaml:"project"`
+	Provider          string   `yaml:"provider"`
+	Region            string   `yaml:"region"`
+	Resource          Resource `yaml:"resource"`
+	Snapshot          bool     `yaml:"snapshot"`
+	SnapshotRetention int      `yaml:"snapshot_retention"`
+	Zone              string   `yaml:"zone"`
+
+	timeout Timeout
+	walk    *walk
 }
 
 // Resource stores declared resources in a group.
 type Resource struct {
-	VM VM `yaml:"vm"`
+	Container Container `yaml:"container"`
+	GKE       []GKE     `yaml:"gke"`
+	MIG       []MIG     `yaml:"mig"`
+	SQL       []SQL     `yaml:"sql"`
+	VM        VM        `yaml:"vm"`
+}
+
+// SQL stores details about Cloud SQL instance resource.
+type SQL struct {
+	Name string `yaml:"name"`
+}
+
+// GKE stores details about Google Kubernetes Engine cluster resource.
+type GKE struct {
+	Cluster  string     `yaml:"cluster"`
+	Location string     `yaml:"location"`
+	NodePool []NodePool `yaml:"node_pool"`
+}
+
+// NodePool stores details of a node pool in GKE resource.
+type NodePool struct {
+	Autoscaling Autoscaling `yaml:"autoscaling"`
+	Name        string      `yaml:"name"`
+	NodeCount   int         `yaml:"node_count"`
+}
+
+// Autoscaling stores the autoscaling bounds of a node pool.
+type Autoscaling struct {
+	Max int `yaml:"max"`
+	Min int `yaml:"min"`
+}
+
+// MIG stores details about managed instance group resource.
+type MIG struct {
+	Name string `yaml:"name"`
+	Size int    `yaml:"size"`
+}
+
+// Container stores details about container resource.
+type Container struct {
+	Instance []ContainerInstance `yaml:"instance"`
+	Runtime  string              `yaml:"runtime"`
+	Script   ContainerScript     `yaml:"script"`
+}
+
+// ContainerInstance stores details of a container or a compose project in container resource.
+type ContainerInstance struct {
+	Compose bool            `yaml:"compose"`
+	Name    string          `yaml:"name"`
+	Script  ContainerScript `yaml:"script"`
+	Service string          `yaml:"service"`
+}
+
+// ContainerScript stores shell commands executed inside a container.
+type ContainerScript struct {
+	Down []string `yaml:"down"`
+	Up   []string `yaml:"up"`
 }
 
 // VM stores details about Virtual Machine resource.
 type VM struct {
 	Instance []Instance `yaml:"instance"`
 	Script   EnvScript  `yaml:"script"`
+	Selector Selector   `yaml:"selector"`
+}
+
+// Selector stores criteria to select instances at runtime, in addition to the declared instances.
+type Selector struct {
+	Labels map[string]string `yaml:"labels"`
+	Name   string            `yaml:"name"`
 }
 
 // Instance stores details of an instance in Virtual Machine resource.
 type Instance struct {
-	Name   string    `yaml:"name"`
-	Record Record    `yaml:"record"`
-	Script EnvScript `yaml:"script"`
+	DependsOn         []string  `yaml:"depends_on"`
+	DownMode          string    `yaml:"down_mode"`
+	MachineType       string    `yaml:"machine_type"`
+	Name              string    `yaml:"name"`
+	Ready             Ready     `yaml:"ready"`
+	Record            Record    `yaml:"record"`
+	Script            EnvScript `yaml:"script"`
+	Snapshot          bool      `yaml:"snapshot"`
+	SnapshotRetention int       `yaml:"snapshot_retention"`
 }
 
+// Ready stores probes that must pass before the up scripts of an instance are executed.
+type Ready struct {
+	GuestAttribute string `yaml:"guest_attribute"`
+	HTTP           string `yaml:"http"`
+	Serial         string `yaml:"serial"`
+	TCP            string `yaml:"tcp"`
+	Timeout        string `yaml:"timeout"`
+}
+
+// Modes to bring an instance into Down state.
+const (
+	DownModeStop    = "stop"
+	DownModeSuspend = "suspend"
+)
+
 // EnvScript stores shell commands.
 type EnvScript struct {
 	Down []string `yaml:"down"`
@@ -74,22 +186,45 @@ type SSH struct {
 // Record stores details to create a DNS record.
 type Record struct {
 	Domain     string   `yaml:"domain"`
+	Endpoint   string   `yaml:"endpoint"`
 	ExternalIP bool     `yaml:"external_ip"`
 	IP         []string `yaml:"ip"`
+	OnDown     string   `yaml:"on_down"`
+	Park       Park     `yaml:"park"`
+	Project    string   `yaml:"project"`
+	Provider   string   `yaml:"provider"`
+	Proxied    bool     `yaml:"proxied"`
+	Server     string   `yaml:"server"`
+	TokenFile  string   `yaml:"token_file"`
+	TSIG       TSIG     `yaml:"tsig"`
 	Type       string   `yaml:"type"`
 	Zone       string   `yaml:"zone"`
 }
 
+// TSIG stores the key that signs the dynamic updates of a record.
+type TSIG struct {
+	Algorithm  string `yaml:"algorithm"`
+	Key        string `yaml:"key"`
+	SecretFile string `yaml:"secret_file"`
+}
+
 // Config stores options from parsed flags.
 type Config struct {
-	Name   string       `json:"name"`
-	Label  string       `json:"label"`
-	All    bool         `json:"all"`
-	Data   Environments `json:"data"`
-	File   string
-	Host   string
-	Dry    bool
-	Format string
+	Name       string       `json:"name"`
+	Label      string       `json:"label"`
+	All        bool         `json:"all"`
+	Data       Environments `json:"data"`
+	File       string
+	Filter     []string
+	Host       string
+	Dry        bool
+	Format     string
+	Interval   time.Duration
+	MaxRetries int
+	Parallel   int
+	Projects   []string
+	Timeout    []string
+	Zone       string
 }
 
 // GetConfig will get configuration from JSON.
@@ -110,18 +245,35 @@ func (c *Config) ParseFlags(args []string) error {
 	f.StringVar(&c.File, "env-file", envFile, "environment file")
 	f.StringVar(&c.File, "e", envFile, "environment file")
 
+	f.Var((*flagutil.StringList)(&c.Filter), "filter", "instance label filter in format label=value")
+
 	f.StringVar(&c.Format, "format", "", "Output format of API request")
 	f.StringVar(&c.Format, "f", "", "Output format of API request")
 
 	f.StringVar(&c.Host, "host", "", "Server host that will execute the commands")
 	f.StringVar(&c.Host, "h", "", "Server host that will execute the commands")
 
+	f.DurationVar(&c.Interval, "interval", time.Minute, "interval between checks of preemptible instances")
+
 	f.StringVar(&c.Label, "label", "", "environment label")
 	f.StringVar(&c.Label, "l", "", "environment label")
 
+	f.IntVar(&c.MaxRetries, "max-retries", 3, "maximum consecutive failed restarts of a preemptible instance")
+
 	f.StringVar(&c.Name, "name", "", "environment name")
+	f.StringVar(&c.Name, "env", "", "environment name")
 	f.StringVar(&c.Name, "n", "", "environment name")
 
+	f.IntVar(&c.Parallel, "parallel", 0, "maximum number of instances of a group managed concurrently")
+
+	f.Var((*flagutil.StringList)(&c.Projects), "project", "Google Cloud Project ID")
+	f.Var((*flagutil.StringList)(&c.Projects), "p", "Google Cloud Project ID")
+
+	f.Var((*flagutil.StringList)(&c.Timeout), "timeout", "timeout of an operation kind in format kind=duration")
+
+	f.StringVar(&c.Zone, "zone", "", "Google Cloud Zone name")
+	f.StringVar(&c.Zone, "z", "", "Google Cloud Zone name")
+
 	f.Usage = func() { fmt.Printf("missing or wrong option(s)\nfor usage information type:\n  rcstate env help\n\n") }
 
 	if err := f.Parse(args[1:]); err != nil {
@@ -159,6 +311,22 @@ func (c *Config) ParseEnvironmentFile() error {
 		return fmt.Errorf("unmarshal template: %w", err)
 	}
 
+	for i := range c.Data.Envs {
+		if err := c.Data.Envs[i].Timeout.override(c.Timeout); err != nil {
+			return fmt.Errorf("environment %q: %w", c.Data.Envs[i].Name, err)
+		}
+
+		if _, err := c.Data.Envs[i].dependencies(); err != nil {
+			return fmt.Errorf("environment %q: %w", c.Data.Envs[i].Name, err)
+		}
+
+		if err := c.Data.Envs[i].validateRecords(); err != nil {
+			return fmt.Errorf("environment %q: %w", c.Data.Envs[i].Name, err)
+		}
+
+		c.Data.Envs[i].overrideParallel(c.Parallel)
+	}
+
 	return nil
 }
 
@@ -241,100 +409,403 @@ func (env *Environment) CheckLabel(label string) bool {
 }
 
 // State manages the state of an environment.
-func (env *Environment) State(state string) {
-	for _, g := range env.Group {
-		vm := gce.NewInstances(g.Project, g.Zone)
-		for _, instance := range g.Resource.VM.Instance {
-			switch state {
-			case "up":
-				groupStateUp(vm, g, instance)
-			case "down":
-				groupStateDown(vm, g, instance)
-			}
+// Groups and instances are brought up after their dependencies, and brought down before them.
+// A group or an instance is skipped if one it waits for failed.
+// It stops before the next group or instance when the context is done.
+//
+// On Up state, the failure policy of the environment decides whether it continues after a failure,
+// stops, or stops and rolls back the instances started and the DNS records changed.
+func (env *Environment) State(ctx context.Context, state string) {
+	if err := env.Timeout.validate(); err != nil {
+		fmt.Printf("environment %q: %s\n", env.Name, err)
+		return
+	}
+
+	policy, err := env.onFailure()
+	if err != nil {
+		fmt.Printf("environment %q: %s\n", env.Name, err)
+		return
+	}
+
+	if err := env.validateRecords(); err != nil {
+		fmt.Printf("environment %q: %s\n", env.Name, err)
+		return
+	}
+
+	deps, err := env.dependencies()
+	if err != nil {
+		fmt.Printf("environment %q: %s\n", env.Name, err)
+		return
+	}
+
+	env.markActive(state)
+
+	w := newWalk(deps, state)
+	w.stop = state == "up" && policy != OnFailureContinue
+
+	for _, i := range deps.order(env.Group, w.down) {
+		g := env.Group[i]
+
+		if err := ctx.Err(); err != nil {
+			fmt.Printf("environment %q: %s: %s\n", env.Name, state, err)
+			break
 		}
+
+		if w.halted() {
+			fmt.Printf("environment %q: %s: stopped after a failure\n", env.Name, state)
+			break
+		}
+
+		if failed := w.groups.failed(deps.groups.blocking(g.Name, w.down)); failed != "" {
+			fmt.Printf("group %q: skipped, group %q failed\n", g.Name, failed)
+			w.groups.set(g.Name, false)
+			continue
+		}
+
+		g.timeout = env.Timeout
+		g.walk = w
+
+		w.groups.set(g.Name, g.state(ctx, state) == nil)
+	}
+
+	if state == "up" && policy == OnFailureRollback && w.failed() {
+		env.rollback(w)
 	}
 }
 
-// groupStateUp brings a group into Up state.
-func groupStateUp(vm *gce.Instances, g Group, instance Instance) {
-	if err := vm.Start(instance.Name); err != nil {
-		fmt.Println("group state up: vm start:", err)
+// state brings the resources of a group into the state, and returns the errors of the failed resources.
+func (g *Group) state(ctx context.Context, state string) error {
+	switch state {
+	case "up":
+		return errors.Join(
+			g.sqlState(ctx, state),
+			g.vmState(ctx, state),
+			g.migState(ctx, state),
+			g.gkeState(ctx, state),
+			g.containerState(ctx, state),
+		)
+	case "down":
+		return errors.Join(
+			g.containerState(ctx, state),
+			g.gkeState(ctx, state),
+			g.migState(ctx, state),
+			g.vmState(ctx, state),
+			g.sqlState(ctx, state),
+		)
 	}
 
-	if instance.Record.Domain != "" {
-		g.instanceRecord(instance)
+	return nil
+}
+
+// vmState manages the state of virtual machines in a group, and returns an error if an instance failed.
+func (g *Group) vmState(ctx context.Context, state string) error {
+	if len(g.Resource.VM.Instance) == 0 && g.Resource.VM.Selector.empty() {
+		return nil
 	}
 
-	host := getHost(instance, g.Project, g.Zone)
+	p, err := g.newProvider()
+	if err != nil {
+		fmt.Printf("group %q: %s\n", g.Name, err)
+		return err
+	}
 
-	if len(g.Resource.VM.Script.Up) > 0 {
-		for _, cmd := range g.Resource.VM.Script.Up {
-			cmd = strings.ReplaceAll(cmd, "&gt;", ">")
-			g.Resource.VM.Script.execute(host, cmd)
-		}
+	instances, err := g.Instances(ctx, p)
+	if err != nil {
+		fmt.Printf("group %q: %s\n", g.Name, err)
+	}
+
+	workers, err := g.workers(len(instances))
+	if err != nil {
+		fmt.Printf("group %q: %s\n", g.Name, err)
+		return err
 	}
 
-	if len(instance.Script.Up) > 0 {
-		for _, cmd := range instance.Script.Up {
-			cmd = strings.ReplaceAll(cmd, "&gt;", ">")
-			instance.Script.execute(host, cmd)
+	g.walk.walkInstances(ctx, workers, g.Name, instances, func(instance Instance, out io.Writer) error {
+		switch state {
+		case "up":
+			return groupStateUp(ctx, p, *g, instance, out)
+		case "down":
+			return groupStateDown(ctx, p, *g, instance, out)
+		}
+
+		return nil
+	})
+
+	for _, instance := range instances {
+		if failed := g.walk.instances.failed([]string{instanceNode(g.Name, instance.Name)}); failed != "" {
+			return fmt.Errorf("instance %q failed", failed)
 		}
 	}
+
+	return ctx.Err()
 }
 
-// groupStateUp brings a group into Down state.
-func groupStateDown(vm *gce.Instances, g Group, instance Instance) {
-	host := getHost(instance, g.Project, g.Zone)
+// computeProvider returns the compute provider selected by the options.
+var computeProvider = compute.NewProvider
+
+// newProvider returns the compute provider declared for the group.
+func (g *Group) newProvider() (provider.Provider, error) {
+	return computeProvider(provider.Options{
+		Endpoint: g.Endpoint,
+		Name:     g.Provider,
+		Project:  g.Project,
+		Region:   g.Region,
+		Zone:     g.Zone,
+	})
+}
+
+// groupStateUp brings an instance of a group into Up state, and returns the errors of the failed steps.
+func groupStateUp(ctx context.Context, p provider.Provider, g Group, instance Instance, out io.Writer) error {
+	startCtx, cancel := g.timeout.context(ctx, timeoutStart)
+	defer cancel()
+
+	var errs []error
+
+	if err := g.applyMachineType(startCtx, p, instance, out); err != nil {
+		fmt.Fprintln(out, "group state up: vm machine type:", err)
+		errs = append(errs, err)
+	}
 
-	if len(instance.Script.Down) > 0 {
-		for _, cmd := range instance.Script.Down {
-			cmd = strings.ReplaceAll(cmd, "&gt;", ">")
-			g.Resource.VM.Script.execute(host, cmd)
+	status, err := p.Status(startCtx, instance.Name)
+	if err != nil {
+		fmt.Fprintln(out, "group state up: vm status:", err)
+	}
+
+	if err := startInstance(startCtx, p, instance.Name); err != nil {
+		fmt.Fprintln(out, "group state up: vm start:", err)
+		return errors.Join(append(errs, err)...)
+	}
+
+	if status != "RUNNING" && g.walk != nil {
+		g.walk.changes.started(p, g, instance)
+	}
+
+	return errors.Join(append(errs, instanceUp(ctx, p, g, instance, out))...)
+}
+
+// instanceUp creates the DNS record and executes the up scripts of a started instance,
+// and returns the errors of the failed steps.
+func instanceUp(ctx context.Context, p provider.Provider, g Group, instance Instance, out io.Writer) error {
+	var errs []error
+
+	if instance.Record.Domain != "" {
+		dnsCtx, cancel := g.timeout.context(ctx, timeoutDNS)
+		changes, err := instanceRecord(dnsCtx, p, g, instance, out)
+		if err != nil {
+			fmt.Fprintln(out, "instance record:", err)
+			errs = append(errs, err)
+		}
+		cancel()
+
+		for _, change := range changes {
+			if g.walk != nil {
+				g.walk.changes.changed(change)
+			}
 		}
 	}
 
-	if len(g.Resource.VM.Script.Down) > 0 {
-		for _, cmd := range g.Resource.VM.Script.Down {
-			cmd = strings.ReplaceAll(cmd, "&gt;", ">")
-			instance.Script.execute(host, cmd)
+	host := getHost(ctx, p, instance, out)
+
+	if !instance.Ready.empty() {
+		d, err := instance.waitReady(ctx, p, host)
+		if err != nil {
+			fmt.Fprintf(out, "group state up: instance %q: %s\n", instance.Name, err)
+			return errors.Join(append(errs, err)...)
+		}
+
+		fmt.Fprintf(out, "instance %q is ready after %s\n", instance.Name, d.Round(time.Second))
+	}
+
+	errs = append(errs,
+		g.Resource.VM.Script.run(ctx, g.timeout, host, g.Resource.VM.Script.Up, out),
+		instance.Script.run(ctx, g.timeout, host, instance.Script.Up, out),
+	)
+
+	return errors.Join(errs...)
+}
+
+// groupStateDown brings an instance of a group into Down state, and returns the errors of the failed steps.
+func groupStateDown(ctx context.Context, p provider.Provider, g Group, instance Instance, out io.Writer) error {
+	host := getHost(ctx, p, instance, out)
+
+	errs := []error{
+		g.Resource.VM.Script.run(ctx, g.timeout, host, instance.Script.Down, out),
+		instance.Script.run(ctx, g.timeout, host, g.Resource.VM.Script.Down, out),
+	}
+
+	snapshotCtx, cancel := g.timeout.context(ctx, timeoutSnapshot)
+	defer cancel()
+
+	if err := snapshotInstance(snapshotCtx, p, g, instance); err != nil {
+		fmt.Fprintln(out, "group state down: vm snapshot:", err)
+		errs = append(errs, err)
+	}
+
+	stopCtx, cancel := g.timeout.context(ctx, timeoutStop)
+	defer cancel()
+
+	mode := instance.downMode(g)
+	if err := stopInstance(stopCtx, p, mode, instance.Name); err != nil {
+		fmt.Fprintln(out, "group state down: vm stop:", err)
+		return errors.Join(append(errs, err)...)
+	}
+
+	if mode == DownModeStop {
+		if err := g.restoreMachineType(stopCtx, p, instance); err != nil {
+			fmt.Fprintln(out, "group state down: vm machine type:", err)
+			errs = append(errs, err)
 		}
 	}
 
-	if err := vm.Stop(instance.Name); err != nil {
-		fmt.Println("group state down: vm stop:", err)
+	dnsCtx, cancel := g.timeout.context(ctx, timeoutDNS)
+	defer cancel()
+
+	if err := instanceRecordDown(dnsCtx, g, instance, out); err != nil {
+		fmt.Fprintln(out, "group state down: instance record:", err)
+		errs = append(errs, err)
 	}
+
+	return errors.Join(errs...)
 }
 
-// instanceRecord creates the DNS record for an instance.
-func (g *Group) instanceRecord(inst Instance) {
-	if inst.Record.ExternalIP {
-		externalIP, err := gce.GetInstanceExternalIP(inst.Name, g.Project, g.Zone)
+// downMode returns the down mode of the instance, inherited from the group if not declared.
+func (inst *Instance) downMode(g Group) string {
+	if inst.DownMode != "" {
+		return inst.DownMode
+	}
+
+	if g.DownMode != "" {
+		return g.DownMode
+	}
+
+	return DownModeStop
+}
+
+// startInstance resumes a suspended instance, or starts the instance otherwise.
+func startInstance(ctx context.Context, p provider.Provider, name string) error {
+	if s, ok := p.(provider.Suspender); ok {
+		status, err := p.Status(ctx, name)
 		if err != nil {
-			fmt.Printf("create record: get external IP address: %s", err)
-			return
+			return err
 		}
 
-		if externalIP == "<nil>" {
-			fmt.Println("create record: instance does not have external IP address")
+		if status == "SUSPENDED" {
+			return s.Resume(ctx, name)
 		}
+	}
+
+	return p.Start(ctx, name)
+}
 
-		if externalIP != "<nil>" {
-			inst.Record.IP = append(inst.Record.IP, externalIP)
+// stopInstance stops or suspends the instance based on the down mode.
+func stopInstance(ctx context.Context, p provider.Provider, mode string, name string) error {
+	switch mode {
+	case DownModeStop:
+		return p.Stop(ctx, name)
+	case DownModeSuspend:
+		s, ok := p.(provider.Suspender)
+		if !ok {
+			return fmt.Errorf("suspend instance %q: not supported by the provider", name)
 		}
+		return s.Suspend(ctx, name)
+	default:
+		return fmt.Errorf("unknown down mode %q", mode)
 	}
+}
 
-	if record.CheckRecordIP(inst.Record.Zone, inst.Record.IP) {
-		return
+// dnsProvider returns the DNS provider of the record.
+// Cloud DNS managed zones are looked up in the project of the group, if the record does not declare a project.
+func (g *Group) dnsProvider(r Record) (record.Provider, error) {
+	project := r.Project
+	if project == "" {
+		project = g.Project
+	}
+
+	return record.NewProvider(record.Options{
+		Name:      r.Provider,
+		Project:   project,
+		Endpoint:  r.Endpoint,
+		Proxied:   r.Proxied,
+		Server:    r.Server,
+		TokenFile: r.TokenFile,
+		TSIG: record.TSIG{
+			Algorithm:  r.TSIG.Algorithm,
+			Name:       r.TSIG.Key,
+			SecretFile: r.TSIG.SecretFile,
+		},
+	})
+}
+
+// instanceRecord creates the DNS record for an instance, and returns the changes of the records.
+// A record parked at a CNAME is removed before the record is created.
+func instanceRecord(ctx context.Context, p provider.Provider, g Group, inst Instance, out io.Writer) ([]recordChange, error) {
+	dns, err := g.dnsProvider(inst.Record)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := record.NewRecord(inst.Record.IP, inst.Record.Type, inst.Record.Zone, inst.Record.Domain).Route53(); err != nil {
-		fmt.Println("instance record: new record:", err)
-		return
+	ips, err := inst.recordIPs(ctx, p, out)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("record %s %s has no IP address", inst.Record.Zone, inst.Record.Type)
+	}
+
+	var changes []recordChange
+
+	unparked, err := unparkRecord(ctx, dns, inst.Record)
+	if err != nil {
+		return nil, fmt.Errorf("unpark record: %w", err)
+	}
+
+	if unparked != nil {
+		changes = append(changes, *unparked)
+	}
+
+	rec := record.NewRecord(ips, inst.Record.Type, inst.Record.Zone, inst.Record.Domain)
+
+	status, err := record.Check(ctx, dns, rec)
+	if err != nil {
+		return changes, err
+	}
+
+	if status.UpToDate() {
+		return changes, nil
+	}
+
+	if err := dns.Upsert(ctx, rec); err != nil {
+		return changes, fmt.Errorf("new record: %w", err)
+	}
+
+	return append(changes, recordChange{dns: dns, record: rec, previous: status.Current}), nil
+}
+
+// recordIPs returns the IP addresses of the DNS record of the instance,
+// with the external IP address of the instance if the record uses it.
+func (inst *Instance) recordIPs(ctx context.Context, p provider.Provider, out io.Writer) ([]string, error) {
+	ips := append([]string{}, inst.Record.IP...)
+
+	if !inst.Record.ExternalIP {
+		return ips, nil
+	}
+
+	externalIP, err := p.ExternalIP(ctx, inst.Name)
+	if err != nil {
+		return nil, fmt.Errorf("get external IP address: %w", err)
+	}
+
+	if externalIP == "" {
+		fmt.Fprintln(out, "create record: instance does not have external IP address")
+		return ips, nil
 	}
+
+	return append(ips, externalIP), nil
 }
 
 // getHost return a valid host address.
-func getHost(inst Instance, p string, z string) string {
+func getHost(ctx context.Context, p provider.Provider, inst Instance, out io.Writer) string {
 	if inst.Record.Zone != "" {
 		return inst.Record.Zone
 	}
@@ -343,23 +814,44 @@ func getHost(inst Instance, p string, z string) string {
 		return inst.Record.IP[0]
 	}
 
-	ip, err := gce.GetInstanceExternalIP(inst.Name, p, z)
+	ip, err := p.ExternalIP(ctx, inst.Name)
 	if err != nil {
-		fmt.Println("host external IP:", err)
+		fmt.Fprintln(out, "host external IP:", err)
 	}
 
 	return ip
 }
 
-// execute will execute a shell command.
-func (s *EnvScript) execute(host string, cmd string) {
+// run will execute the shell commands with the SSH configuration of the script,
+// and returns the errors of the failed commands.
+func (s *EnvScript) run(ctx context.Context, t Timeout, host string, cmds []string, out io.Writer) error {
+	var errs []error
+
+	for _, cmd := range cmds {
+		cmd = strings.ReplaceAll(cmd, "&gt;", ">")
+		errs = append(errs, s.execute(ctx, t, host, cmd, out))
+	}
+
+	return errors.Join(errs...)
+}
+
+// execute will execute a shell command within the script timeout, and write its output to out.
+func (s *EnvScript) execute(ctx context.Context, t Timeout, host string, cmd string, out io.Writer) error {
 	script, err := ssh.NewSSH(host, s.SSH.Port, s.SSH.User, s.SSH.Key)
 	if err != nil {
-		fmt.Println("env script:", err)
-		return
+		fmt.Fprintln(out, "env script:", err)
+		return err
 	}
 
-	if err := script.CMD(cmd); err != nil {
-		fmt.Println("env script cmd:", err)
+	script.Output = out
+
+	ctx, cancel := t.context(ctx, timeoutScript)
+	defer cancel()
+
+	if err := script.CMD(ctx, cmd); err != nil {
+		fmt.Fprintln(out, "env script cmd:", err)
+		return err
 	}
+
+	return nil
 }
@@ -0,0 +1,172 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/marintailor/rcstate/cmd/api/container"
+)
+
+// containerState manages the state of containers in a group, and returns the errors of the failed containers.
+// Containers are managed concurrently in a parallel group, with the output of each container prefixed with its name.
+// No new container is started after the context is done.
+func (g *Group) containerState(ctx context.Context, state string) error {
+	if len(g.Resource.Container.Instance) == 0 {
+		return nil
+	}
+
+	rt, err := container.NewRuntime(g.Resource.Container.Runtime)
+	if err != nil {
+		fmt.Printf("group %q: %s\n", g.Name, err)
+		return err
+	}
+
+	workers, err := g.workers(len(g.Resource.Container.Instance))
+	if err != nil {
+		fmt.Printf("group %q: %s\n", g.Name, err)
+		return err
+	}
+
+	var (
+		errs []error
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+	)
+
+	sem := make(chan struct{}, workers)
+
+	for _, c := range g.Resource.Container.Instance {
+		if err := ctx.Err(); err != nil {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(c ContainerInstance) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			var err error
+
+			if workers == 1 {
+				err = g.containerInstanceState(ctx, rt, c, state, os.Stdout)
+			} else {
+				out := newPrefixWriter(os.Stdout, c.Name)
+				err = g.containerInstanceState(ctx, rt, c, state, out)
+				out.Flush()
+			}
+
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}(c)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// containerInstanceState brings a container of the group into the state, and writes its output to out.
+func (g *Group) containerInstanceState(ctx context.Context, rt *container.Runtime, c ContainerInstance, state string, out io.Writer) error {
+	switch state {
+	case "up":
+		return containerStateUp(ctx, g.timeout, rt, g.Resource.Container.Script, c, out)
+	case "down":
+		return containerStateDown(ctx, g.timeout, rt, g.Resource.Container.Script, c, out)
+	}
+
+	return nil
+}
+
+// containerStateUp starts a container and runs the up scripts inside it.
+func containerStateUp(ctx context.Context, t Timeout, rt *container.Runtime, s ContainerScript, c ContainerInstance, out io.Writer) error {
+	startCtx, cancel := t.context(ctx, timeoutStart)
+	defer cancel()
+
+	if err := rt.Start(startCtx, c.Name, c.Compose); err != nil {
+		fmt.Fprintln(out, "container state up:", err)
+		return err
+	}
+
+	return errors.Join(
+		s.execute(ctx, t, rt, c, s.Up, out),
+		c.Script.execute(ctx, t, rt, c, c.Script.Up, out),
+	)
+}
+
+// containerStateDown runs the down scripts inside a container and stops it.
+func containerStateDown(ctx context.Context, t Timeout, rt *container.Runtime, s ContainerScript, c ContainerInstance, out io.Writer) error {
+	errs := []error{
+		c.Script.execute(ctx, t, rt, c, c.Script.Down, out),
+		s.execute(ctx, t, rt, c, s.Down, out),
+	}
+
+	stopCtx, cancel := t.context(ctx, timeoutStop)
+	defer cancel()
+
+	if err := rt.Stop(stopCtx, c.Name, c.Compose); err != nil {
+		fmt.Fprintln(out, "container state down:", err)
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// execute will execute shell commands inside the container, each within the script timeout,
+// writes their output to out, and returns the errors of the failed commands.
+func (s *ContainerScript) execute(ctx context.Context, t Timeout, rt *container.Runtime, c ContainerInstance, cmds []string, out io.Writer) error {
+	var errs []error
+
+	for _, cmd := range cmds {
+		cmd = strings.ReplaceAll(cmd, "&gt;", ">")
+
+		cmdCtx, cancel := t.context(ctx, timeoutScript)
+		if err := rt.Exec(cmdCtx, c.Name, c.Compose, c.Service, cmd, out); err != nil {
+			fmt.Fprintln(out, "container script cmd:", err)
+			errs = append(errs, err)
+		}
+		cancel()
+	}
+
+	return errors.Join(errs...)
+}
+
+// GetDetailsContainer will get details about containers of the group for show command.
+func (g *Group) GetDetailsContainer(ctx context.Context) []container.Container {
+	var list []container.Container
+
+	if len(g.Resource.Container.Instance) == 0 {
+		return list
+	}
+
+	rt, err := container.NewRuntime(g.Resource.Container.Runtime)
+	if err != nil {
+		fmt.Println("list containers:", err)
+		return list
+	}
+
+	for _, c := range g.Resource.Container.Instance {
+		status, err := rt.Status(ctx, c.Name, c.Compose)
+		if err != nil {
+			fmt.Println("list containers:", err)
+		}
+
+		list = append(list, container.Container{
+			Name:    c.Name,
+			Compose: c.Compose,
+			Status:  status,
+		})
+	}
+
+	return list
+}
@@ -0,0 +1,412 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// dependencies stores the dependencies between the groups, and between the instances of an environment.
+// Instances are identified by the group name and the instance name, separated by slash.
+type dependencies struct {
+	groups    graph
+	instances graph
+}
+
+// graph stores the nodes that each node depends on, and the nodes that depend on each node.
+type graph struct {
+	deps       map[string][]string
+	dependents map[string][]string
+}
+
+// newGraph returns an empty graph.
+func newGraph() graph {
+	return graph{
+		deps:       map[string][]string{},
+		dependents: map[string][]string{},
+	}
+}
+
+// add adds the dependency of the node on dep, once.
+func (gr graph) add(node string, dep string) {
+	for _, d := range gr.deps[node] {
+		if d == dep {
+			return
+		}
+	}
+
+	gr.deps[node] = append(gr.deps[node], dep)
+	gr.dependents[dep] = append(gr.dependents[dep], node)
+}
+
+// blocking returns the nodes that must be done before the node.
+// Dependencies are done first on Up state, and dependents are done first on Down state.
+func (gr graph) blocking(node string, down bool) []string {
+	if down {
+		return gr.dependents[node]
+	}
+
+	return gr.deps[node]
+}
+
+// cycle returns a dependency cycle of the graph as a list of nodes, or nil if there is none.
+func (gr graph) cycle(nodes []string) []string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	marks := map[string]int{}
+	var path []string
+
+	var visit func(node string) []string
+	visit = func(node string) []string {
+		switch marks[node] {
+		case visiting:
+			for i, n := range path {
+				if n == node {
+					return append(append([]string{}, path[i:]...), node)
+				}
+			}
+		case visited:
+			return nil
+		}
+
+		marks[node] = visiting
+		path = append(path, node)
+
+		for _, dep := range gr.deps[node] {
+			if c := visit(dep); c != nil {
+				return c
+			}
+		}
+
+		path = path[:len(path)-1]
+		marks[node] = visited
+
+		return nil
+	}
+
+	for _, node := range nodes {
+		if c := visit(node); c != nil {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// instanceNode returns the node of an instance in the dependency graph.
+func instanceNode(group string, instance string) string {
+	return group + "/" + instance
+}
+
+// dependencies returns the dependencies declared in the environment.
+// It fails if a dependency is unknown, or the dependencies form a cycle.
+//
+// An instance depends on instances of the same group by name,
+// and on instances of another group by "group/instance".
+// The group of the instance then depends on the other group.
+func (env *Environment) dependencies() (dependencies, error) {
+	d := dependencies{
+		groups:    newGraph(),
+		instances: newGraph(),
+	}
+
+	var groups, instances []string
+	declared := map[string]bool{}
+
+	for _, g := range env.Group {
+		groups = append(groups, g.Name)
+		declared[g.Name] = true
+
+		for _, inst := range g.Resource.VM.Instance {
+			node := instanceNode(g.Name, inst.Name)
+			instances = append(instances, node)
+			declared[node] = true
+		}
+	}
+
+	for _, g := range env.Group {
+		for _, dep := range g.DependsOn {
+			if !declared[dep] || strings.Contains(dep, "/") {
+				return d, fmt.Errorf("group %q depends on unknown group %q", g.Name, dep)
+			}
+
+			d.groups.add(g.Name, dep)
+		}
+
+		for _, inst := range g.Resource.VM.Instance {
+			for _, dep := range inst.DependsOn {
+				group, name, ok := strings.Cut(dep, "/")
+				if !ok {
+					group, name = g.Name, dep
+				}
+
+				node := instanceNode(group, name)
+				if !declared[node] {
+					return d, fmt.Errorf("instance %q of group %q depends on unknown instance %q", inst.Name, g.Name, dep)
+				}
+
+				d.instances.add(instanceNode(g.Name, inst.Name), node)
+
+				if group != g.Name {
+					d.groups.add(g.Name, group)
+				}
+			}
+		}
+	}
+
+	if c := d.groups.cycle(groups); c != nil {
+		return d, fmt.Errorf("dependency cycle between groups: %s", strings.Join(c, " -> "))
+	}
+
+	if c := d.instances.cycle(instances); c != nil {
+		return d, fmt.Errorf("dependency cycle between instances: %s", strings.Join(c, " -> "))
+	}
+
+	return d, nil
+}
+
+// order returns the indexes of the groups in the order they are brought into the state.
+// Groups are kept in declared order, unless they have to wait for their dependencies on Up state,
+// or for their dependents on Down state.
+func (d dependencies) order(groups []Group, down bool) []int {
+	var order []int
+	done := map[string]bool{}
+	added := make([]bool, len(groups))
+
+	for len(order) < len(groups) {
+		progress := false
+
+		for i, g := range groups {
+			if added[i] || !d.ready(g.Name, down, done) {
+				continue
+			}
+
+			order = append(order, i)
+			added[i] = true
+			progress = true
+
+			if !d.pending(g.Name, groups, added) {
+				done[g.Name] = true
+			}
+		}
+
+		if !progress {
+			break
+		}
+	}
+
+	for i := range groups {
+		if !added[i] {
+			order = append(order, i)
+		}
+	}
+
+	return order
+}
+
+// ready returns whether all groups that must be done before the group are done.
+func (d dependencies) ready(group string, down bool, done map[string]bool) bool {
+	for _, b := range d.groups.blocking(group, down) {
+		if !done[b] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pending returns whether a group with the name is not yet ordered.
+func (d dependencies) pending(name string, groups []Group, added []bool) bool {
+	for i, g := range groups {
+		if g.Name == name && !added[i] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// results stores whether the nodes of a walk succeeded, safe for concurrent use.
+type results struct {
+	mu sync.Mutex
+	ok map[string]bool
+}
+
+// newResults returns an empty results.
+func newResults() *results {
+	return &results{ok: map[string]bool{}}
+}
+
+// set records whether the node succeeded. A failure of a node is kept.
+func (r *results) set(node string, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if prev, done := r.ok[node]; done && !prev {
+		return
+	}
+
+	r.ok[node] = ok
+}
+
+// done returns whether the node is done.
+func (r *results) done(node string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, done := r.ok[node]
+
+	return done
+}
+
+// anyFailed returns whether a node failed.
+func (r *results) anyFailed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, ok := range r.ok {
+		if !ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// failed returns the first of the nodes that failed, or an empty string.
+func (r *results) failed(nodes []string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, node := range nodes {
+		if ok, done := r.ok[node]; done && !ok {
+			return node
+		}
+	}
+
+	return ""
+}
+
+// walk stores the dependencies of an environment, and the results and changes of bringing it into a state.
+// No new group or instance is started after a failure, if the walk stops on failure.
+type walk struct {
+	changes   changes
+	deps      dependencies
+	down      bool
+	groups    *results
+	instances *results
+	stop      bool
+}
+
+// newWalk returns a walk of the environment for the state.
+func newWalk(deps dependencies, state string) *walk {
+	return &walk{
+		deps:      deps,
+		down:      state == "down",
+		groups:    newResults(),
+		instances: newResults(),
+	}
+}
+
+// failed returns whether a group or an instance failed.
+func (w *walk) failed() bool {
+	return w.groups.anyFailed() || w.instances.anyFailed()
+}
+
+// halted returns whether the walk stops on failure, and a group or an instance failed.
+func (w *walk) halted() bool {
+	return w.stop && w.failed()
+}
+
+// walkInstances calls fn for each instance of the group, with at most workers calls running concurrently.
+// An instance waits for the instances it is blocked by, and is skipped if one of them failed.
+// The output of concurrent calls is prefixed with the instance name.
+// No new call is started after the context is done, or the walk is halted.
+func (w *walk) walkInstances(ctx context.Context, workers int, group string, instances []Instance, fn func(Instance, io.Writer) error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	local := map[string]bool{}
+	for _, inst := range instances {
+		local[instanceNode(group, inst.Name)] = true
+	}
+
+	type result struct {
+		node string
+		err  error
+	}
+
+	done := make(chan result)
+	running := 0
+	pending := instances
+
+	for len(pending) > 0 || running > 0 {
+		var next []Instance
+		progress := false
+
+		for _, inst := range pending {
+			node := instanceNode(group, inst.Name)
+			blocking := w.deps.instances.blocking(node, w.down)
+
+			if failed := w.instances.failed(blocking); failed != "" {
+				fmt.Printf("instance %q: skipped, %q failed\n", node, failed)
+				w.instances.set(node, false)
+				progress = true
+				continue
+			}
+
+			if running >= workers || ctx.Err() != nil || w.halted() || !w.ready(blocking, local) {
+				next = append(next, inst)
+				continue
+			}
+
+			running++
+			progress = true
+
+			go func(inst Instance, node string) {
+				if workers == 1 {
+					done <- result{node: node, err: fn(inst, os.Stdout)}
+					return
+				}
+
+				out := newPrefixWriter(os.Stdout, inst.Name)
+				err := fn(inst, out)
+				out.Flush()
+
+				done <- result{node: node, err: err}
+			}(inst, node)
+		}
+
+		pending = next
+
+		if running == 0 {
+			if !progress || ctx.Err() != nil || w.halted() {
+				return
+			}
+			continue
+		}
+
+		res := <-done
+		running--
+		w.instances.set(res.node, res.err == nil)
+	}
+}
+
+// ready returns whether the blocking instances of this walk are done.
+// Instances of other groups are done before the group is walked.
+func (w *walk) ready(blocking []string, local map[string]bool) bool {
+	for _, b := range blocking {
+		if local[b] && !w.instances.done(b) {
+			return false
+		}
+	}
+
+	return true
+}
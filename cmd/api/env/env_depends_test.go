@@ -0,0 +1,200 @@
+package env
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDependenciesOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []Group
+		up     []int
+		down   []int
+	}{
+		{
+			name:   "declared order",
+			groups: []Group{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+			up:     []int{0, 1, 2},
+			down:   []int{0, 1, 2},
+		},
+		{
+			name:   "group dependency",
+			groups: []Group{{Name: "app", DependsOn: []string{"db"}}, {Name: "db"}, {Name: "cache"}},
+			up:     []int{1, 2, 0},
+			down:   []int{0, 1, 2},
+		},
+		{
+			name:   "chain",
+			groups: []Group{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", DependsOn: []string{"c"}}, {Name: "c"}},
+			up:     []int{2, 1, 0},
+			down:   []int{0, 1, 2},
+		},
+		{
+			name: "instance dependency across groups",
+			groups: []Group{
+				{Name: "app", Resource: Resource{VM: VM{Instance: []Instance{{Name: "web", DependsOn: []string{"db/main"}}}}}},
+				{Name: "db", Resource: Resource{VM: VM{Instance: []Instance{{Name: "main"}}}}},
+			},
+			up:   []int{1, 0},
+			down: []int{0, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := Environment{Group: tt.groups}
+
+			deps, err := env.dependencies()
+			if err != nil {
+				t.Fatalf("dependencies: %s", err)
+			}
+
+			if got := deps.order(env.Group, false); !reflect.DeepEqual(got, tt.up) {
+				t.Errorf("up order = %v, want %v", got, tt.up)
+			}
+
+			if got := deps.order(env.Group, true); !reflect.DeepEqual(got, tt.down) {
+				t.Errorf("down order = %v, want %v", got, tt.down)
+			}
+		})
+	}
+}
+
+func TestDependenciesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []Group
+		err    string
+	}{
+		{
+			name:   "unknown group",
+			groups: []Group{{Name: "a", DependsOn: []string{"b"}}},
+			err:    `group "a" depends on unknown group "b"`,
+		},
+		{
+			name: "unknown instance",
+			groups: []Group{
+				{Name: "a", Resource: Resource{VM: VM{Instance: []Instance{{Name: "x", DependsOn: []string{"y"}}}}}},
+			},
+			err: `instance "x" of group "a" depends on unknown instance "y"`,
+		},
+		{
+			name:   "group cycle",
+			groups: []Group{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", DependsOn: []string{"a"}}},
+			err:    "dependency cycle between groups: a -> b -> a",
+		},
+		{
+			name: "instance cycle",
+			groups: []Group{
+				{Name: "a", Resource: Resource{VM: VM{Instance: []Instance{
+					{Name: "x", DependsOn: []string{"y"}},
+					{Name: "y", DependsOn: []string{"x"}},
+				}}}},
+			},
+			err: "dependency cycle between instances: a/x -> a/y -> a/x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := Environment{Group: tt.groups}
+
+			_, err := env.dependencies()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("dependencies error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestGraphCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges [][2]string
+		nodes []string
+		want  []string
+	}{
+		{
+			name:  "no cycle",
+			edges: [][2]string{{"a", "b"}, {"b", "c"}, {"a", "c"}},
+			nodes: []string{"a", "b", "c"},
+		},
+		{
+			name:  "self",
+			edges: [][2]string{{"a", "a"}},
+			nodes: []string{"a"},
+			want:  []string{"a", "a"},
+		},
+		{
+			name:  "indirect",
+			edges: [][2]string{{"a", "b"}, {"b", "c"}, {"c", "b"}},
+			nodes: []string{"a", "b", "c"},
+			want:  []string{"b", "c", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gr := newGraph()
+			for _, e := range tt.edges {
+				gr.add(e[0], e[1])
+			}
+
+			if got := gr.cycle(tt.nodes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cycle = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateOrder(t *testing.T) {
+	groups := []Group{
+		vmGroup("app", []string{"db"}, Instance{Name: "web", DependsOn: []string{"api"}}, Instance{Name: "api"}),
+		vmGroup("db", nil, Instance{Name: "main"}),
+	}
+
+	tests := []struct {
+		state string
+		want  []string
+	}{
+		{state: "up", want: []string{"start main", "start api", "start web"}},
+		{state: "down", want: []string{"stop web", "stop api", "stop main"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			f := newFakeProvider()
+			useFakeProvider(t, f)
+
+			env := Environment{Name: "test", Group: groups}
+			env.State(context.Background(), tt.state)
+
+			if !reflect.DeepEqual(f.calls, tt.want) {
+				t.Errorf("calls = %v, want %v", f.calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateSkipsDependents(t *testing.T) {
+	f := newFakeProvider("main")
+	useFakeProvider(t, f)
+
+	env := Environment{
+		Name: "test",
+		Group: []Group{
+			vmGroup("app", []string{"db"}, Instance{Name: "web"}),
+			vmGroup("db", nil, Instance{Name: "main"}),
+			vmGroup("cache", nil, Instance{Name: "redis"}),
+		},
+	}
+	env.State(context.Background(), "up")
+
+	want := []string{"start main", "start redis"}
+	if !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("calls = %v, want %v", f.calls, want)
+	}
+}
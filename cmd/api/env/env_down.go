@@ -1,18 +1,21 @@
 package env
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // Down will shutdown one or all environments based on provided config..
-func (c *Config) Down() (string, error) {
+func (c *Config) Down(ctx context.Context) (string, error) {
 	if c.Name != "" {
-		return c.DownSingle()
+		return c.DownSingle(ctx)
 	}
 
-	return c.DownAll()
+	return c.DownAll(ctx)
 }
 
 // DownSingle will shutdown an environment.
-func (c *Config) DownSingle() (string, error) {
+func (c *Config) DownSingle(ctx context.Context) (string, error) {
 	data, err := c.GetData()
 	if err != nil {
 		return "", fmt.Errorf("marshal env: %w", err)
@@ -32,13 +35,13 @@ func (c *Config) DownSingle() (string, error) {
 		return fmt.Sprintf("{ \"error\": \"environment %q with label %q not found\"}", c.Name, c.Label), nil
 	}
 
-	env.State("down")
+	env.State(ctx, "down")
 
 	return "{ \"status\": \"success\" }", nil
 }
 
 // DownAll will shutdown all environments.
-func (c *Config) DownAll() (string, error) {
+func (c *Config) DownAll(ctx context.Context) (string, error) {
 	data, err := c.GetData()
 	if err != nil {
 		return "", fmt.Errorf("marshal env: %w", err)
@@ -51,7 +54,7 @@ func (c *Config) DownAll() (string, error) {
 
 	for _, env := range e.Envs {
 		if env.CheckLabel(c.Label) {
-			env.State("down")
+			env.State(ctx, "down")
 		}
 	}
 
@@ -0,0 +1,156 @@
+package env
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/marintailor/rcstate/cmd/api/gke"
+	"github.com/marintailor/rcstate/cmd/api/state"
+)
+
+// gkeState manages the state of GKE node pools in a group, and returns the errors of the failed node pools.
+func (g *Group) gkeState(ctx context.Context, s string) error {
+	var errs []error
+
+	for _, k := range g.Resource.GKE {
+		location := k.Location
+		if location == "" {
+			location = g.Zone
+		}
+
+		cluster := gke.NewCluster(g.Project, location, k.Cluster)
+		for _, np := range k.NodePool {
+			switch s {
+			case "up":
+				opCtx, cancel := g.timeout.context(ctx, timeoutStart)
+				if err := nodePoolStateUp(opCtx, cluster, np); err != nil {
+					fmt.Println("node pool state up:", err)
+					errs = append(errs, err)
+				}
+				cancel()
+			case "down":
+				opCtx, cancel := g.timeout.context(ctx, timeoutStop)
+				if err := nodePoolStateDown(opCtx, cluster, np); err != nil {
+					fmt.Println("node pool state down:", err)
+					errs = append(errs, err)
+				}
+				cancel()
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// nodePoolKey returns the key of the recorded size of a node pool.
+func nodePoolKey(c *gke.Cluster, pool string) string {
+	return fmt.Sprintf("gke/%s/%s/%s/%s", c.Project, c.Location, c.Name, pool)
+}
+
+// nodePoolStateUp scales a node pool to the declared or recorded node count and autoscaling bounds.
+func nodePoolStateUp(ctx context.Context, c *gke.Cluster, np NodePool) error {
+	var target gke.NodePool
+
+	v, ok, err := state.Get(nodePoolKey(c, np.Name))
+	if err != nil {
+		return fmt.Errorf("get recorded size: %w", err)
+	}
+
+	if ok {
+		if err := json.Unmarshal([]byte(v), &target); err != nil {
+			return fmt.Errorf("recorded size: %w", err)
+		}
+	}
+
+	if np.NodeCount > 0 {
+		target.NodeCount = np.NodeCount
+		target.Autoscaling = false
+	}
+
+	if np.Autoscaling.Max > 0 {
+		target.Autoscaling = true
+		target.MinNodes = np.Autoscaling.Min
+		target.MaxNodes = np.Autoscaling.Max
+	}
+
+	size := target.NodeCount
+	if target.Autoscaling && size < target.MinNodes {
+		size = target.MinNodes
+	}
+
+	if size == 0 && !target.Autoscaling {
+		return fmt.Errorf("node pool %q has no declared or recorded size", np.Name)
+	}
+
+	if size > 0 {
+		if err := c.SetSize(ctx, np.Name, size); err != nil {
+			return err
+		}
+	}
+
+	if target.Autoscaling {
+		if err := c.SetAutoscaling(ctx, np.Name, true, target.MinNodes, target.MaxNodes); err != nil {
+			return err
+		}
+	}
+
+	if err := state.Delete(nodePoolKey(c, np.Name)); err != nil {
+		return fmt.Errorf("delete recorded size: %w", err)
+	}
+
+	return nil
+}
+
+// nodePoolStateDown records the node count and autoscaling bounds of a node pool, and scales it to zero.
+func nodePoolStateDown(ctx context.Context, c *gke.Cluster, np NodePool) error {
+	current, err := c.GetNodePool(ctx, np.Name)
+	if err != nil {
+		return err
+	}
+
+	if current.NodeCount > 0 || current.Autoscaling {
+		data, err := json.Marshal(current)
+		if err != nil {
+			return fmt.Errorf("marshal size: %w", err)
+		}
+
+		if err := state.Set(nodePoolKey(c, np.Name), string(data)); err != nil {
+			return fmt.Errorf("record size: %w", err)
+		}
+	}
+
+	if current.Autoscaling {
+		if err := c.SetAutoscaling(ctx, np.Name, false, 0, 0); err != nil {
+			return err
+		}
+	}
+
+	return c.SetSize(ctx, np.Name, 0)
+}
+
+// GetDetailsGKE will get details about GKE node pools of the group for show command.
+func (g *Group) GetDetailsGKE(ctx context.Context) []gke.NodePool {
+	var list []gke.NodePool
+
+	for _, k := range g.Resource.GKE {
+		location := k.Location
+		if location == "" {
+			location = g.Zone
+		}
+
+		cluster := gke.NewCluster(g.Project, location, k.Cluster)
+		for _, np := range k.NodePool {
+			pool, err := cluster.GetNodePool(ctx, np.Name)
+			if err != nil {
+				fmt.Println("list node pools:", err)
+				continue
+			}
+
+			list = append(list, pool)
+		}
+	}
+
+	return list
+}
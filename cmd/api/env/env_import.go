@@ -0,0 +1,173 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/marintailor/rcstate/cmd/api/gce"
+	"github.com/marintailor/rcstate/cmd/api/provider"
+	"gopkg.in/yaml.v2"
+)
+
+// importFile stores the skeleton of an environment file generated by import command.
+type importFile struct {
+	Vars map[string]string   `yaml:"variable,omitempty"`
+	Envs []importEnvironment `yaml:"environment"`
+}
+
+// importEnvironment stores an environment generated by import command.
+type importEnvironment struct {
+	Name  string        `yaml:"name"`
+	Label string        `yaml:"label,omitempty"`
+	Group []importGroup `yaml:"group"`
+}
+
+// importGroup stores a group generated by import command.
+type importGroup struct {
+	Name     string         `yaml:"name"`
+	Project  string         `yaml:"project"`
+	Zone     string         `yaml:"zone"`
+	Resource importResource `yaml:"resource"`
+}
+
+// importResource stores resources of a group generated by import command.
+type importResource struct {
+	VM importVM `yaml:"vm"`
+}
+
+// importVM stores virtual machines of a group generated by import command.
+type importVM struct {
+	Instance []importInstance `yaml:"instance"`
+}
+
+// importInstance stores an instance generated by import command.
+type importInstance struct {
+	Name   string        `yaml:"name"`
+	Record *importRecord `yaml:"record,omitempty"`
+}
+
+// importRecord stores the DNS record of an instance generated by import command.
+type importRecord struct {
+	Domain     string `yaml:"domain"`
+	ExternalIP bool   `yaml:"external_ip"`
+	Type       string `yaml:"type"`
+	Zone       string `yaml:"zone"`
+}
+
+// Import returns an environment file generated from the existing instances in the projects.
+// Instances are listed from all zones if no zone is provided.
+func (c *Config) Import(ctx context.Context) (string, error) {
+	if len(c.Projects) == 0 {
+		return "", fmt.Errorf("no project was provided")
+	}
+
+	selector, err := filterSelector(c.Filter)
+	if err != nil {
+		return "", err
+	}
+
+	name := c.Name
+	if name == "" {
+		name = "imported"
+	}
+
+	out := importFile{}
+	env := importEnvironment{
+		Name:  name,
+		Label: c.Label,
+	}
+
+	for _, project := range c.Projects {
+		instances := gce.NewInstances(project, c.Zone)
+
+		var list []provider.Instance
+		if c.Zone == "" {
+			list, err = instances.GetAggregatedList(ctx)
+		} else {
+			list, err = instances.GetList(ctx)
+		}
+		if err != nil {
+			return "", fmt.Errorf("list instances in project %q: %w", project, err)
+		}
+
+		groups := importGroups(project, list, selector)
+		for _, g := range groups {
+			for _, inst := range g.Resource.VM.Instance {
+				if inst.Record != nil {
+					out.Vars = map[string]string{"DNS_DOMAIN": "example.com"}
+				}
+			}
+		}
+
+		env.Group = append(env.Group, groups...)
+	}
+
+	out.Envs = []importEnvironment{env}
+
+	data, err := yaml.Marshal(out)
+	if err != nil {
+		return "", fmt.Errorf("marshal environment file: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// filterSelector returns a selector with labels from filters in format "key=value".
+func filterSelector(filters []string) (Selector, error) {
+	s := Selector{Labels: map[string]string{}}
+
+	for _, f := range filters {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok {
+			return s, fmt.Errorf("filter %q: expected format label=value", f)
+		}
+		s.Labels[k] = v
+	}
+
+	return s, nil
+}
+
+// importGroups returns a group per zone with the instances that match the selector.
+func importGroups(project string, list []provider.Instance, s Selector) []importGroup {
+	byZone := map[string][]importInstance{}
+
+	for _, inst := range list {
+		if !s.match(inst, nil) {
+			continue
+		}
+
+		i := importInstance{Name: inst.Name}
+		if inst.External != "" {
+			i.Record = &importRecord{
+				Domain:     "{{ .DNS_DOMAIN }}",
+				ExternalIP: true,
+				Type:       "A",
+				Zone:       inst.Name + ".{{ .DNS_DOMAIN }}",
+			}
+		}
+
+		byZone[inst.Zone] = append(byZone[inst.Zone], i)
+	}
+
+	zones := make([]string, 0, len(byZone))
+	for z := range byZone {
+		zones = append(zones, z)
+	}
+	sort.Strings(zones)
+
+	var groups []importGroup
+	for _, z := range zones {
+		groups = append(groups, importGroup{
+			Name:    fmt.Sprintf("%s-%s", project, z),
+			Project: project,
+			Zone:    z,
+			Resource: importResource{
+				VM: importVM{Instance: byZone[z]},
+			},
+		})
+	}
+
+	return groups
+}
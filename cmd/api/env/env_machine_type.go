@@ -0,0 +1,81 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/marintailor/rcstate/cmd/api/provider"
+	"github.com/marintailor/rcstate/cmd/api/state"
+)
+
+// machineTypeKey returns the key of the recorded original machine type of an instance.
+func (g *Group) machineTypeKey(name string) string {
+	return fmt.Sprintf("machine_type/%s/%s/%s/%s/%s", g.Provider, g.Project, g.Region, g.Zone, name)
+}
+
+// applyMachineType changes the machine type of a stopped instance to the declared one,
+// and records the original machine type to be restored on Down state.
+// The change is skipped with a warning for an instance that is not stopped, and applied on a later Up state.
+func (g *Group) applyMachineType(ctx context.Context, p provider.Provider, inst Instance, out io.Writer) error {
+	if inst.MachineType == "" {
+		return nil
+	}
+
+	mt, ok := p.(provider.MachineTyper)
+	if !ok {
+		return fmt.Errorf("changing machine type is not supported by the provider")
+	}
+
+	current, err := mt.MachineType(ctx, inst.Name)
+	if err != nil {
+		return err
+	}
+
+	if current == inst.MachineType {
+		return nil
+	}
+
+	status, err := p.Status(ctx, inst.Name)
+	if err != nil {
+		return err
+	}
+
+	if status != "TERMINATED" && status != "STOPPED" {
+		fmt.Fprintf(out, "warning: instance %q is %s, machine type %s is applied on the next up after it is stopped\n", inst.Name, status, inst.MachineType)
+		return nil
+	}
+
+	if _, ok, err := state.Get(g.machineTypeKey(inst.Name)); err != nil {
+		return fmt.Errorf("get recorded machine type: %w", err)
+	} else if !ok {
+		if err := state.Set(g.machineTypeKey(inst.Name), current); err != nil {
+			return fmt.Errorf("record machine type: %w", err)
+		}
+	}
+
+	return mt.SetMachineType(ctx, inst.Name, inst.MachineType)
+}
+
+// restoreMachineType changes the machine type of a stopped instance back to the recorded original one.
+func (g *Group) restoreMachineType(ctx context.Context, p provider.Provider, inst Instance) error {
+	original, ok, err := state.Get(g.machineTypeKey(inst.Name))
+	if err != nil {
+		return fmt.Errorf("get recorded machine type: %w", err)
+	}
+
+	if !ok {
+		return nil
+	}
+
+	mt, ok := p.(provider.MachineTyper)
+	if !ok {
+		return fmt.Errorf("changing machine type is not supported by the provider")
+	}
+
+	if err := mt.SetMachineType(ctx, inst.Name, original); err != nil {
+		return err
+	}
+
+	return state.Delete(g.machineTypeKey(inst.Name))
+}
@@ -0,0 +1,126 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/marintailor/rcstate/cmd/api/gce"
+	"github.com/marintailor/rcstate/cmd/api/provider"
+	"github.com/marintailor/rcstate/cmd/api/state"
+)
+
+// migState manages the state of managed instance groups in a group,
+// and returns the errors of the failed instance groups.
+func (g *Group) migState(ctx context.Context, s string) error {
+	if len(g.Resource.MIG) == 0 {
+		return nil
+	}
+
+	if g.Provider != "" && g.Provider != provider.GCE {
+		err := fmt.Errorf("managed instance groups are not supported by provider %q", g.Provider)
+		fmt.Printf("group %q: %s\n", g.Name, err)
+		return err
+	}
+
+	var errs []error
+
+	igs := gce.NewInstanceGroups(g.Project, g.Zone)
+	for _, m := range g.Resource.MIG {
+		switch s {
+		case "up":
+			opCtx, cancel := g.timeout.context(ctx, timeoutStart)
+			if err := migStateUp(opCtx, igs, m); err != nil {
+				fmt.Println("mig state up:", err)
+				errs = append(errs, err)
+			}
+			cancel()
+		case "down":
+			opCtx, cancel := g.timeout.context(ctx, timeoutStop)
+			if err := migStateDown(opCtx, igs, m); err != nil {
+				fmt.Println("mig state down:", err)
+				errs = append(errs, err)
+			}
+			cancel()
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// migKey returns the key of the recorded target size of a managed instance group.
+func migKey(igs *gce.InstanceGroups, name string) string {
+	return fmt.Sprintf("mig/%s/%s/%s", igs.Project, igs.Zone, name)
+}
+
+// migStateUp resizes a managed instance group to the declared or recorded size,
+// and waits until its instances are running.
+func migStateUp(ctx context.Context, igs *gce.InstanceGroups, m MIG) error {
+	size := m.Size
+	if size == 0 {
+		v, ok, err := state.Get(migKey(igs, m.Name))
+		if err != nil {
+			return fmt.Errorf("get recorded size: %w", err)
+		}
+
+		if ok {
+			size, err = strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("recorded size %q: %w", v, err)
+			}
+		}
+	}
+
+	if size == 0 {
+		return fmt.Errorf("instance group %q has no declared or recorded size", m.Name)
+	}
+
+	if err := igs.Resize(ctx, m.Name, size); err != nil {
+		return err
+	}
+
+	if err := igs.WaitRunning(ctx, m.Name, size); err != nil {
+		return err
+	}
+
+	if err := state.Delete(migKey(igs, m.Name)); err != nil {
+		return fmt.Errorf("delete recorded size: %w", err)
+	}
+
+	return nil
+}
+
+// migStateDown records the target size of a managed instance group and resizes it to zero.
+func migStateDown(ctx context.Context, igs *gce.InstanceGroups, m MIG) error {
+	mig, err := igs.Get(ctx, m.Name)
+	if err != nil {
+		return err
+	}
+
+	if mig.TargetSize > 0 {
+		if err := state.Set(migKey(igs, m.Name), strconv.Itoa(mig.TargetSize)); err != nil {
+			return fmt.Errorf("record size: %w", err)
+		}
+	}
+
+	return igs.Resize(ctx, m.Name, 0)
+}
+
+// GetDetailsMIG will get details about managed instance groups of the group for show command.
+func (g *Group) GetDetailsMIG(ctx context.Context) []gce.InstanceGroup {
+	var list []gce.InstanceGroup
+
+	igs := gce.NewInstanceGroups(g.Project, g.Zone)
+	for _, m := range g.Resource.MIG {
+		mig, err := igs.Get(ctx, m.Name)
+		if err != nil {
+			fmt.Println("list instance groups:", err)
+			continue
+		}
+
+		list = append(list, mig)
+	}
+
+	return list
+}
@@ -0,0 +1,118 @@
+package env
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// outputMu serializes the lines written by instances managed concurrently.
+var outputMu sync.Mutex
+
+// overrideParallel sets the number of concurrent instances of the groups without a declared number.
+// Groups with "parallel: false" are still managed sequentially.
+func (env *Environment) overrideParallel(n int) {
+	if n <= 0 {
+		return
+	}
+
+	for i, g := range env.Group {
+		if g.Parallel == "" {
+			env.Group[i].Parallel = strconv.Itoa(n)
+			continue
+		}
+
+		if _, err := strconv.Atoi(g.Parallel); err == nil {
+			continue
+		}
+
+		if parallel, err := strconv.ParseBool(g.Parallel); err == nil && parallel {
+			env.Group[i].Parallel = strconv.Itoa(n)
+		}
+	}
+}
+
+// workers returns the number of instances of the group that are managed concurrently.
+// The value "true" manages all instances concurrently.
+func (g *Group) workers(instances int) (int, error) {
+	if g.Parallel == "" {
+		return 1, nil
+	}
+
+	if n, err := strconv.Atoi(g.Parallel); err == nil {
+		if n < 1 {
+			return 0, fmt.Errorf("parallel %q: must be a positive number", g.Parallel)
+		}
+		return n, nil
+	}
+
+	parallel, err := strconv.ParseBool(g.Parallel)
+	if err != nil {
+		return 0, fmt.Errorf("parallel %q: must be true, false, or a number", g.Parallel)
+	}
+
+	if !parallel || instances < 1 {
+		return 1, nil
+	}
+
+	return instances, nil
+}
+
+// prefixWriter writes each complete line to the underlying writer with a prefix.
+// It is used by a single goroutine, and the lines of all prefix writers are serialized.
+type prefixWriter struct {
+	buf    []byte
+	prefix string
+	w      io.Writer
+}
+
+// newPrefixWriter returns a prefixWriter that prefixes the lines with the name.
+func newPrefixWriter(w io.Writer, name string) *prefixWriter {
+	return &prefixWriter{
+		prefix: "[" + name + "] ",
+		w:      w,
+	}
+}
+
+// Write writes the complete lines of b, and buffers the incomplete last line.
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		if err := p.writeLine(p.buf[:i+1]); err != nil {
+			return 0, err
+		}
+
+		p.buf = p.buf[i+1:]
+	}
+
+	return len(b), nil
+}
+
+// Flush writes the buffered incomplete line.
+func (p *prefixWriter) Flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+
+	line := append(p.buf, '\n')
+	p.buf = nil
+
+	return p.writeLine(line)
+}
+
+// writeLine writes a line with the prefix.
+func (p *prefixWriter) writeLine(line []byte) error {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	_, err := fmt.Fprintf(p.w, "%s%s", p.prefix, line)
+	return err
+}
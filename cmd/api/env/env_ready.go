@@ -0,0 +1,180 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/marintailor/rcstate/cmd/api/provider"
+)
+
+// Default timing of readiness probes.
+const (
+	defaultReadyTimeout = 5 * time.Minute
+	readyInterval       = 5 * time.Second
+	readyProbeTimeout   = 10 * time.Second
+)
+
+// probe stores a readiness check of an instance.
+type probe struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// empty returns true if no readiness probe is declared.
+func (r *Ready) empty() bool {
+	return r.GuestAttribute == "" && r.HTTP == "" && r.Serial == "" && r.TCP == ""
+}
+
+// timeout returns the declared timeout of readiness probes, or the default timeout.
+func (r *Ready) timeout() (time.Duration, error) {
+	if r.Timeout == "" {
+		return defaultReadyTimeout, nil
+	}
+
+	d, err := time.ParseDuration(r.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("parse timeout %q: %w", r.Timeout, err)
+	}
+
+	return d, nil
+}
+
+// probes returns the declared readiness probes of an instance.
+func (r *Ready) probes(p provider.Provider, name string, host string) ([]probe, error) {
+	var probes []probe
+
+	if r.TCP != "" {
+		probes = append(probes, probe{
+			name:  "tcp:" + r.TCP,
+			check: func(ctx context.Context) error { return tcpReady(ctx, host, r.TCP) },
+		})
+	}
+
+	if r.HTTP != "" {
+		probes = append(probes, probe{
+			name:  "http:" + r.HTTP,
+			check: func(ctx context.Context) error { return httpReady(ctx, r.HTTP) },
+		})
+	}
+
+	if r.GuestAttribute == "" && r.Serial == "" {
+		return probes, nil
+	}
+
+	g, ok := p.(provider.GuestReader)
+	if !ok {
+		return nil, fmt.Errorf("guest attribute and serial probes are not supported by the provider")
+	}
+
+	if r.GuestAttribute != "" {
+		probes = append(probes, probe{
+			name: "guest_attribute:" + r.GuestAttribute,
+			check: func(ctx context.Context) error {
+				v, err := g.GuestAttribute(ctx, name, r.GuestAttribute)
+				if err != nil {
+					return err
+				}
+				if v == "" {
+					return fmt.Errorf("guest attribute %q is empty", r.GuestAttribute)
+				}
+				return nil
+			},
+		})
+	}
+
+	if r.Serial != "" {
+		probes = append(probes, probe{
+			name: "serial:" + r.Serial,
+			check: func(ctx context.Context) error {
+				out, err := g.SerialOutput(ctx, name)
+				if err != nil {
+					return err
+				}
+				if !strings.Contains(out, r.Serial) {
+					return fmt.Errorf("marker %q not found in serial port output", r.Serial)
+				}
+				return nil
+			},
+		})
+	}
+
+	return probes, nil
+}
+
+// waitReady waits until all readiness probes of the instance pass, or the timeout is reached.
+// It returns the time spent waiting.
+func (inst *Instance) waitReady(ctx context.Context, p provider.Provider, host string) (time.Duration, error) {
+	start := time.Now()
+
+	timeout, err := inst.Ready.timeout()
+	if err != nil {
+		return 0, err
+	}
+
+	probes, err := inst.Ready.probes(p, inst.Name, host)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for _, pr := range probes {
+		for {
+			probeCtx, cancelProbe := context.WithTimeout(ctx, readyProbeTimeout)
+			err := pr.check(probeCtx)
+			cancelProbe()
+
+			if err == nil {
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return time.Since(start), fmt.Errorf("probe %s: not ready after %s: %w", pr.name, time.Since(start).Round(time.Second), err)
+			case <-time.After(readyInterval):
+			}
+		}
+	}
+
+	return time.Since(start), nil
+}
+
+// tcpReady checks that the port accepts TCP connections on the host.
+func tcpReady(ctx context.Context, host string, port string) error {
+	if host == "" {
+		return fmt.Errorf("instance does not have a host address")
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+// httpReady checks that the URL responds with a successful status code.
+func httpReady(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
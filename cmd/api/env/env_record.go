@@ -0,0 +1,257 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/marintailor/rcstate/cmd/api/provider"
+	"github.com/marintailor/rcstate/cmd/api/record"
+)
+
+// Policies applied to the DNS record of an instance brought into Down state.
+const (
+	OnDownDelete = "delete"
+	OnDownKeep   = "keep"
+	OnDownPark   = "park"
+)
+
+// Park stores the maintenance target of a parked DNS record, either IP addresses or a CNAME.
+type Park struct {
+	CNAME string   `yaml:"cname"`
+	IP    []string `yaml:"ip"`
+}
+
+// onDown returns the on_down policy of the record, keep if not declared.
+func (r *Record) onDown() (string, error) {
+	switch r.OnDown {
+	case "":
+		return OnDownKeep, nil
+	case OnDownKeep, OnDownDelete:
+		return r.OnDown, nil
+	case OnDownPark:
+		if (r.Park.CNAME == "") == (len(r.Park.IP) == 0) {
+			return "", fmt.Errorf("on_down %q requires either park.ip or park.cname", r.OnDown)
+		}
+		return r.OnDown, nil
+	default:
+		return "", fmt.Errorf("unknown on_down policy %q", r.OnDown)
+	}
+}
+
+// validateRecords ensures that the on_down policies of the instance records are valid.
+func (env *Environment) validateRecords() error {
+	for _, g := range env.Group {
+		for _, inst := range g.Resource.VM.Instance {
+			if _, err := inst.Record.onDown(); err != nil {
+				return fmt.Errorf("instance %q of group %q: record: %w", inst.Name, g.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// instanceRecordDown applies the on_down policy to the DNS record of a stopped instance.
+// The record is deleted, or repointed to the maintenance IP addresses or CNAME of the park target.
+func instanceRecordDown(ctx context.Context, g Group, inst Instance, out io.Writer) error {
+	r := inst.Record
+	if r.Domain == "" {
+		return nil
+	}
+
+	policy, err := r.onDown()
+	if err != nil || policy == OnDownKeep {
+		return err
+	}
+
+	dns, err := g.dnsProvider(r)
+	if err != nil {
+		return err
+	}
+
+	rec := record.NewRecord(nil, r.Type, r.Zone, r.Domain)
+
+	if policy == OnDownDelete {
+		deleted, err := deleteRecord(ctx, dns, rec)
+		if err != nil {
+			return fmt.Errorf("delete record: %w", err)
+		}
+
+		if deleted {
+			fmt.Fprintf(out, "record %s %s: deleted\n", r.Zone, r.Type)
+		}
+
+		return nil
+	}
+
+	if r.Park.CNAME != "" {
+		// A CNAME record can not coexist with other records of the same name.
+		if _, err := deleteRecord(ctx, dns, rec); err != nil {
+			return fmt.Errorf("delete record: %w", err)
+		}
+
+		rec = record.NewRecord([]string{r.Park.CNAME}, "CNAME", r.Zone, r.Domain)
+	} else {
+		rec.IP = r.Park.IP
+	}
+
+	if err := dns.Upsert(ctx, rec); err != nil {
+		return fmt.Errorf("park record: %w", err)
+	}
+
+	fmt.Fprintf(out, "record %s %s: parked at %s\n", r.Zone, rec.Type, strings.Join(rec.IP, ", "))
+
+	return nil
+}
+
+// unparkRecord deletes the CNAME record of a record parked at a CNAME, so the record of the instance can be created,
+// and returns the change if the CNAME record was deleted.
+func unparkRecord(ctx context.Context, dns record.Provider, r Record) (*recordChange, error) {
+	if r.OnDown != OnDownPark || r.Park.CNAME == "" {
+		return nil, nil
+	}
+
+	parked, err := dns.Lookup(ctx, record.NewRecord(nil, "CNAME", r.Zone, r.Domain))
+	if err != nil || parked == nil {
+		return nil, err
+	}
+
+	if err := dns.Delete(ctx, parked); err != nil {
+		return nil, err
+	}
+
+	return &recordChange{dns: dns, record: parked, previous: parked}, nil
+}
+
+// deleteRecord deletes the existing record with the same name and type as the record,
+// and returns whether a record was deleted.
+// The existing record is deleted, as some providers require the values and TTL of a deleted record to match.
+func deleteRecord(ctx context.Context, dns record.Provider, r *record.Record) (bool, error) {
+	existing, err := dns.Lookup(ctx, r)
+	if err != nil || existing == nil {
+		return false, err
+	}
+
+	return true, dns.Delete(ctx, existing)
+}
+
+// RecordCheck stores the comparison of the DNS record of an instance with the current record set of its DNS provider.
+type RecordCheck struct {
+	Err      error
+	Group    string
+	Instance string
+	Record   *record.Record
+	Skipped  string
+	Status   *record.Status
+}
+
+// CheckRecords compares the DNS records of the instances of the environment with the current record sets
+// of their DNS providers.
+// A running instance expects its record, and a stopped instance expects the result of its on_down policy.
+// The record of a stopped instance with the keep policy is skipped, as its addresses are not known.
+func (env *Environment) CheckRecords(ctx context.Context) []RecordCheck {
+	var checks []RecordCheck
+
+	for _, g := range env.Group {
+		if !g.hasRecords() {
+			continue
+		}
+
+		p, err := g.newProvider()
+		if err != nil {
+			checks = append(checks, RecordCheck{Group: g.Name, Err: err})
+			continue
+		}
+
+		instances, err := g.Instances(ctx, p)
+		if err != nil {
+			checks = append(checks, RecordCheck{Group: g.Name, Err: err})
+		}
+
+		for _, inst := range instances {
+			if inst.Record.Domain == "" {
+				continue
+			}
+
+			dnsCtx, cancel := env.Timeout.context(ctx, timeoutDNS)
+			checks = append(checks, g.checkRecord(dnsCtx, p, inst))
+			cancel()
+		}
+	}
+
+	return checks
+}
+
+// hasRecords returns whether an instance of the group declares a DNS record.
+// Instances found by selector are not known before they are listed, so a selector may have records.
+func (g *Group) hasRecords() bool {
+	if !g.Resource.VM.Selector.empty() {
+		return true
+	}
+
+	for _, inst := range g.Resource.VM.Instance {
+		if inst.Record.Domain != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkRecord compares the expected DNS record of an instance with the current record set of its DNS provider.
+func (g *Group) checkRecord(ctx context.Context, p provider.Provider, inst Instance) RecordCheck {
+	c := RecordCheck{Group: g.Name, Instance: inst.Name}
+
+	dns, err := g.dnsProvider(inst.Record)
+	if err != nil {
+		c.Err = err
+		return c
+	}
+
+	c.Record, c.Skipped, c.Err = inst.expectedRecord(ctx, p)
+	if c.Err != nil || c.Skipped != "" {
+		return c
+	}
+
+	c.Status, c.Err = record.Check(ctx, dns, c.Record)
+
+	return c
+}
+
+// expectedRecord returns the DNS record of the instance in its current state,
+// or the reason why the record is skipped.
+func (inst *Instance) expectedRecord(ctx context.Context, p provider.Provider) (*record.Record, string, error) {
+	r := inst.Record
+
+	status, err := p.Status(ctx, inst.Name)
+	if err != nil {
+		return nil, "", fmt.Errorf("vm status: %w", err)
+	}
+
+	if status == "RUNNING" {
+		ips, err := inst.recordIPs(ctx, p, io.Discard)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return record.NewRecord(ips, r.Type, r.Zone, r.Domain), "", nil
+	}
+
+	policy, err := r.onDown()
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch {
+	case policy == OnDownDelete:
+		return record.NewRecord(nil, r.Type, r.Zone, r.Domain), "", nil
+	case policy == OnDownPark && r.Park.CNAME != "":
+		return record.NewRecord([]string{r.Park.CNAME}, "CNAME", r.Zone, r.Domain), "", nil
+	case policy == OnDownPark:
+		return record.NewRecord(r.Park.IP, r.Type, r.Zone, r.Domain), "", nil
+	default:
+		return record.NewRecord(nil, r.Type, r.Zone, r.Domain), fmt.Sprintf("instance is %s, record is kept", status), nil
+	}
+}
@@ -0,0 +1,161 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/marintailor/rcstate/cmd/api/provider"
+	"github.com/marintailor/rcstate/cmd/api/record"
+)
+
+// Policies applied when bringing an environment into Up state fails.
+const (
+	OnFailureContinue = "continue"
+	OnFailureRollback = "rollback"
+	OnFailureStop     = "stop"
+)
+
+// onFailure returns the failure policy of the environment, continue if not declared.
+func (env *Environment) onFailure() (string, error) {
+	switch env.OnFailure {
+	case "":
+		return OnFailureContinue, nil
+	case OnFailureContinue, OnFailureRollback, OnFailureStop:
+		return env.OnFailure, nil
+	default:
+		return "", fmt.Errorf("unknown on_failure policy %q", env.OnFailure)
+	}
+}
+
+// startedInstance stores an instance started during a walk.
+type startedInstance struct {
+	group    Group
+	instance Instance
+	provider provider.Provider
+}
+
+// recordChange stores a DNS record changed during a walk, and the record it replaced.
+// The previous record is nil if the record did not exist.
+type recordChange struct {
+	dns      record.Provider
+	record   *record.Record
+	previous *record.Record
+}
+
+// changes stores the instances started, and the DNS records changed during a walk, safe for concurrent use.
+type changes struct {
+	mu        sync.Mutex
+	instances []startedInstance
+	records   []recordChange
+}
+
+// started records an instance started during the walk.
+func (c *changes) started(p provider.Provider, g Group, inst Instance) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.instances = append(c.instances, startedInstance{group: g, instance: inst, provider: p})
+}
+
+// changed records a DNS record changed during the walk.
+func (c *changes) changed(rc recordChange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.records = append(c.records, rc)
+}
+
+// rollback stops the instances started during the walk, and reverts the changed DNS records,
+// in reverse order, and prints a summary.
+// It is not canceled with the walk, and each operation is bounded by its timeout.
+// The environment is no longer marked as active after the rollback.
+// Cloud SQL instances, managed instance groups, GKE node pools and containers are not rolled back.
+func (env *Environment) rollback(w *walk) {
+	ctx := context.Background()
+
+	fmt.Printf("\nROLLBACK\nenvironment %q failed to come up, rolling back\n", env.Name)
+
+	var stopped, reverted []string
+
+	instances := w.changes.instances
+	for i := len(instances) - 1; i >= 0; i-- {
+		s := instances[i]
+		node := instanceNode(s.group.Name, s.instance.Name)
+
+		if err := rollbackInstance(ctx, s.provider, s.group, s.instance, os.Stdout); err != nil {
+			stopped = append(stopped, fmt.Sprintf("%s (failed: %s)", node, err))
+			continue
+		}
+
+		stopped = append(stopped, node)
+	}
+
+	records := w.changes.records
+	for i := len(records) - 1; i >= 0; i-- {
+		rc := records[i]
+		name := fmt.Sprintf("%s %s", rc.record.Zone, rc.record.Type)
+
+		dnsCtx, cancel := env.Timeout.context(ctx, timeoutDNS)
+
+		var action string
+		var err error
+
+		if rc.previous == nil {
+			action = "deleted"
+			_, err = deleteRecord(dnsCtx, rc.dns, rc.record)
+		} else {
+			action = "restored"
+			err = rc.dns.Upsert(dnsCtx, rc.previous)
+		}
+
+		cancel()
+
+		if err != nil {
+			reverted = append(reverted, fmt.Sprintf("%s (failed: %s)", name, err))
+			continue
+		}
+
+		reverted = append(reverted, fmt.Sprintf("%s (%s)", name, action))
+	}
+
+	fmt.Printf("\nROLLBACK SUMMARY\nINSTANCES STOPPED: %d\n", len(stopped))
+	for i, s := range stopped {
+		fmt.Printf("%d. %s\n", i+1, s)
+	}
+
+	fmt.Printf("DNS RECORDS REVERTED: %d\n", len(reverted))
+	for i, r := range reverted {
+		fmt.Printf("%d. %s\n", i+1, r)
+	}
+
+	fmt.Println("NOT ROLLED BACK: cloud sql instances, managed instance groups, gke node pools, containers")
+
+	// The environment is no longer active, so its stopped instances are not restarted by watch.
+	env.markActive("down")
+}
+
+// rollbackInstance runs the down scripts of an instance started during the walk, and stops or suspends it.
+// The instance is not snapshotted, its machine type is not restored, and its DNS record is left to the revert
+// of the changed DNS records.
+func rollbackInstance(ctx context.Context, p provider.Provider, g Group, instance Instance, out io.Writer) error {
+	host := getHost(ctx, p, instance, out)
+
+	errs := []error{
+		g.Resource.VM.Script.run(ctx, g.timeout, host, instance.Script.Down, out),
+		instance.Script.run(ctx, g.timeout, host, g.Resource.VM.Script.Down, out),
+	}
+
+	stopCtx, cancel := g.timeout.context(ctx, timeoutStop)
+	defer cancel()
+
+	if err := stopInstance(stopCtx, p, instance.downMode(g), instance.Name); err != nil {
+		fmt.Fprintln(out, "rollback: vm stop:", err)
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
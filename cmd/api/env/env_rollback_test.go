@@ -0,0 +1,51 @@
+package env
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestStateOnFailure(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   []string
+		active bool
+	}{
+		{policy: "", want: []string{"start a1", "start a2", "start b1"}, active: true},
+		{policy: OnFailureContinue, want: []string{"start a1", "start a2", "start b1"}, active: true},
+		{policy: OnFailureStop, want: []string{"start a1", "start a2"}, active: true},
+		{policy: OnFailureRollback, want: []string{"start a1", "start a2", "stop a1"}},
+		{policy: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			f := newFakeProvider("a2")
+			useFakeProvider(t, f)
+
+			env := Environment{
+				Name:      "test",
+				OnFailure: tt.policy,
+				Group: []Group{
+					vmGroup("a", nil, Instance{Name: "a1"}, Instance{Name: "a2"}),
+					vmGroup("b", nil, Instance{Name: "b1"}),
+				},
+			}
+			env.State(context.Background(), "up")
+
+			if !reflect.DeepEqual(f.calls, tt.want) {
+				t.Errorf("calls = %v, want %v", f.calls, tt.want)
+			}
+
+			active, err := env.active()
+			if err != nil {
+				t.Fatalf("active: %s", err)
+			}
+
+			if active != tt.active {
+				t.Errorf("active = %v, want %v", active, tt.active)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/marintailor/rcstate/cmd/api/provider"
+)
+
+// empty returns true if the selector has no criteria.
+func (s *Selector) empty() bool {
+	return len(s.Labels) == 0 && s.Name == ""
+}
+
+// match returns true if the instance has all labels of the selector and its name matches the name pattern.
+func (s *Selector) match(inst provider.Instance, name *regexp.Regexp) bool {
+	for k, v := range s.Labels {
+		if inst.Labels[k] != v {
+			return false
+		}
+	}
+
+	return name == nil || name.MatchString(inst.Name)
+}
+
+// resolveInstances returns the declared instances of the group,
+// followed by the instances from the list that match the selector and are not declared.
+func (g *Group) resolveInstances(list []provider.Instance) ([]Instance, error) {
+	instances := append([]Instance{}, g.Resource.VM.Instance...)
+
+	s := g.Resource.VM.Selector
+	if s.empty() {
+		return instances, nil
+	}
+
+	var name *regexp.Regexp
+	if s.Name != "" {
+		var err error
+		name, err = regexp.Compile(s.Name)
+		if err != nil {
+			return instances, fmt.Errorf("selector name %q: %w", s.Name, err)
+		}
+	}
+
+	declared := map[string]bool{}
+	for _, inst := range instances {
+		declared[inst.Name] = true
+	}
+
+	for _, inst := range list {
+		if declared[inst.Name] || declared[inst.ID] || !s.match(inst, name) {
+			continue
+		}
+
+		instances = append(instances, Instance{Name: inst.Name})
+	}
+
+	return instances, nil
+}
+
+// byName returns the listed instances by name, and by ID for providers with instance IDs,
+// as instances are declared by either.
+func byName(list []provider.Instance) map[string]provider.Instance {
+	m := map[string]provider.Instance{}
+	for _, inst := range list {
+		m[inst.Name] = inst
+		if inst.ID != "" {
+			m[inst.ID] = inst
+		}
+	}
+
+	return m
+}
+
+// Instances returns the declared and selected instances of the group.
+// The instances are listed with one call per group, which also serves the
+// status and external IP lookups of providers that cache the list.
+func (g *Group) Instances(ctx context.Context, p provider.Provider) ([]Instance, error) {
+	list, err := p.GetList(ctx)
+	if err != nil {
+		return g.Resource.VM.Instance, fmt.Errorf("list instances: %w", err)
+	}
+
+	return g.resolveInstances(list)
+}
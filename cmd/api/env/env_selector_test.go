@@ -0,0 +1,66 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/marintailor/rcstate/cmd/api/provider"
+)
+
+func TestResolveInstances(t *testing.T) {
+	list := []provider.Instance{
+		{ID: "i-0abc", Name: "web", Labels: map[string]string{"env": "dev"}},
+		{ID: "i-0def", Name: "api", Labels: map[string]string{"env": "dev"}},
+		{Name: "db", Labels: map[string]string{"env": "prod"}},
+	}
+
+	tests := []struct {
+		name     string
+		declared []Instance
+		selector Selector
+		want     []string
+	}{
+		{
+			name:     "declared only",
+			declared: []Instance{{Name: "web"}, {Name: "missing"}},
+			want:     []string{"web", "missing"},
+		},
+		{
+			name:     "selected by label",
+			declared: []Instance{{Name: "db"}},
+			selector: Selector{Labels: map[string]string{"env": "dev"}},
+			want:     []string{"db", "web", "api"},
+		},
+		{
+			name:     "selected by name",
+			selector: Selector{Name: "^(web|db)$"},
+			want:     []string{"web", "db"},
+		},
+		{
+			name:     "declared by ID",
+			declared: []Instance{{Name: "i-0abc"}},
+			selector: Selector{Labels: map[string]string{"env": "dev"}},
+			want:     []string{"i-0abc", "api"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := Group{Resource: Resource{VM: VM{Instance: tt.declared, Selector: tt.selector}}}
+
+			instances, err := g.resolveInstances(list)
+			if err != nil {
+				t.Fatalf("resolve instances: %s", err)
+			}
+
+			var got []string
+			for _, inst := range instances {
+				got = append(got, inst.Name)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("instances = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
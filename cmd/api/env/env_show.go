@@ -1,10 +1,15 @@
 package env
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
+	"github.com/marintailor/rcstate/cmd/api/cloudsql"
+	"github.com/marintailor/rcstate/cmd/api/container"
 	"github.com/marintailor/rcstate/cmd/api/gce"
+	"github.com/marintailor/rcstate/cmd/api/gke"
+	"github.com/marintailor/rcstate/cmd/api/provider"
 )
 
 // ShowEnvironment stores environment information for show command.
@@ -18,66 +23,100 @@ type ShowEnvironment struct {
 type ShowGroup struct {
 	Name     string       `json:"name"`
 	Project  string       `json:"project"`
+	Provider string       `json:"provider"`
+	Region   string       `json:"region"`
 	Zone     string       `json:"zone"`
 	Resource ShowResource `json:"resource"`
 }
 
 // ShowEnvironment stores resource information for show command.
 type ShowResource struct {
-	VM []gce.Instance `json:"vm"`
+	Container []container.Container `json:"container"`
+	GKE       []gke.NodePool        `json:"gke"`
+	MIG       []gce.InstanceGroup   `json:"mig"`
+	Snapshot  []provider.Snapshot   `json:"snapshot"`
+	SQL       []cloudsql.Instance   `json:"sql"`
+	VM        []provider.Instance   `json:"vm"`
 }
 
 // GetDetailsEnv will get details about the environment for show command.
-func (se *ShowEnvironment) GetDetailsEnv(e Environment) {
+func (se *ShowEnvironment) GetDetailsEnv(ctx context.Context, e Environment) {
 	se.Name = e.Name
-	se.GetDetailsGroup(e.Group)
+	se.GetDetailsGroup(ctx, e.Group)
 }
 
 // GetDetailsEnv will get details about the group for show command.
-func (se *ShowEnvironment) GetDetailsGroup(groups []Group) {
+func (se *ShowEnvironment) GetDetailsGroup(ctx context.Context, groups []Group) {
 	for _, g := range groups {
 		group := ShowGroup{}
 		group.Name = g.Name
 		group.Project = g.Project
+		group.Provider = g.Provider
+		group.Region = g.Region
 		group.Zone = g.Zone
-		group.Resource.VM = GetDetailsVM(g.Resource.VM.Instance, g.Project, g.Zone)
+		group.Resource.VM, group.Resource.Snapshot = g.GetDetailsVM(ctx)
+		group.Resource.MIG = g.GetDetailsMIG(ctx)
+		group.Resource.GKE = g.GetDetailsGKE(ctx)
+		group.Resource.SQL = g.GetDetailsSQL(ctx)
+		group.Resource.Container = g.GetDetailsContainer(ctx)
 
 		se.Group = append(se.Group, group)
 	}
 
 }
 
-// GetDetailsEnv will get details about virtual machines for show command.
-func GetDetailsVM(insts []Instance, project string, zone string) []gce.Instance {
-	var list []gce.Instance
+// GetDetailsVM will get details about virtual machines of the group, and the latest snapshot of each instance,
+// for show command. The instances are listed with one call per group, and shown in declared order.
+// A declared instance missing from the list is shown with status NOT_FOUND.
+func (g *Group) GetDetailsVM(ctx context.Context) ([]provider.Instance, []provider.Snapshot) {
+	var list []provider.Instance
 
-	instances := *gce.NewInstances(project, zone)
-	if _, err := instances.GetInstancesList(); err != nil {
+	if len(g.Resource.VM.Instance) == 0 && g.Resource.VM.Selector.empty() {
+		return list, nil
+	}
+
+	p, err := g.newProvider()
+	if err != nil {
+		fmt.Println("list instances:", err)
+		return list, nil
+	}
+
+	instances, err := p.GetList(ctx)
+	if err != nil {
 		fmt.Println("list instances:", err)
+		return list, g.snapshotDetails(ctx, p, g.Resource.VM.Instance)
 	}
 
-	for _, instance := range instances.List {
-		for _, inst := range insts {
-			if instance.Name == inst.Name {
-				list = append(list, instance)
-			}
+	insts, err := g.resolveInstances(instances)
+	if err != nil {
+		fmt.Println("list instances:", err)
+	}
+
+	details := byName(instances)
+
+	for _, inst := range insts {
+		instance, ok := details[inst.Name]
+		if !ok {
+			instance = provider.Instance{Name: inst.Name, Status: "NOT_FOUND"}
 		}
+
+		list = append(list, instance)
 	}
 
-	return list
+	return list, g.snapshotDetails(ctx, p, insts)
 }
 
 // Show returns the information about the environment(s).
-func (c *Config) Show() (string, error) {
+func (c *Config) Show(ctx context.Context) (string, error) {
 	if c.Name != "" {
-		return c.ShowSingle()
+		return c.ShowSingle(ctx)
 	}
 
-	return c.ShowAll()
+	return c.ShowAll(ctx)
 }
 
 // ShowSingle returns the information about an environment.
-func (c *Config) ShowSingle() (string, error) {
+func (c *Config) ShowSingle(ctx context.Context) (string, error) {
 	data, err := c.GetData()
 	if err != nil {
 		return "", fmt.Errorf("marshal env: %w", err)
@@ -98,7 +137,7 @@ func (c *Config) ShowSingle() (string, error) {
 	}
 
 	var out ShowEnvironment
-	out.GetDetailsEnv(env)
+	out.GetDetailsEnv(ctx, env)
 
 	json, err := json.Marshal(out)
 	if err != nil {
@@ -109,7 +148,7 @@ func (c *Config) ShowSingle() (string, error) {
 }
 
 // ShowAll returns the information about all environments.
-func (c *Config) ShowAll() (string, error) {
+func (c *Config) ShowAll(ctx context.Context) (string, error) {
 	data, err := c.GetData()
 	if err != nil {
 		return "", fmt.Errorf("marshal env: %w", err)
@@ -124,7 +163,7 @@ func (c *Config) ShowAll() (string, error) {
 	for _, env := range e.Envs {
 		var item ShowEnvironment
 		if env.CheckLabel(c.Label) {
-			item.GetDetailsEnv(env)
+			item.GetDetailsEnv(ctx, env)
 			list = append(list, item)
 		}
 	}
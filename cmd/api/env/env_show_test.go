@@ -0,0 +1,29 @@
+package env
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestGetDetailsVM(t *testing.T) {
+	f := newFakeProvider()
+	f.status["a"] = "TERMINATED"
+	f.status["b"] = "RUNNING"
+	f.status["c"] = "RUNNING"
+	useFakeProvider(t, f)
+
+	g := vmGroup("app", nil, Instance{Name: "b"}, Instance{Name: "missing"}, Instance{Name: "a"})
+
+	list, _ := g.GetDetailsVM(context.Background())
+
+	var got []string
+	for _, inst := range list {
+		got = append(got, inst.Name+" "+inst.Status)
+	}
+
+	want := []string{"b RUNNING", "missing NOT_FOUND", "a TERMINATED"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("instances = %v, want %v", got, want)
+	}
+}
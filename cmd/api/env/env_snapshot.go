@@ -0,0 +1,71 @@
+package env
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/marintailor/rcstate/cmd/api/provider"
+)
+
+// defaultSnapshotRetention is the number of snapshots kept per disk when no retention is declared.
+const defaultSnapshotRetention = 3
+
+// snapshot returns whether the disks of the instance are snapshotted before it is stopped,
+// and the number of snapshots kept per disk.
+func (inst *Instance) snapshot(g Group) (bool, int) {
+	if !inst.Snapshot && !g.Snapshot {
+		return false, 0
+	}
+
+	switch {
+	case inst.SnapshotRetention > 0:
+		return true, inst.SnapshotRetention
+	case g.SnapshotRetention > 0:
+		return true, g.SnapshotRetention
+	default:
+		return true, defaultSnapshotRetention
+	}
+}
+
+// snapshotInstance creates a snapshot of the instance's disks and prunes the older ones.
+func snapshotInstance(ctx context.Context, p provider.Provider, g Group, inst Instance) error {
+	enabled, retention := inst.snapshot(g)
+	if !enabled {
+		return nil
+	}
+
+	s, ok := p.(provider.Snapshotter)
+	if !ok {
+		return fmt.Errorf("snapshot instance %q: not supported by the provider", inst.Name)
+	}
+
+	return s.Snapshot(ctx, inst.Name, retention)
+}
+
+// snapshotDetails returns the latest snapshot of each listed instance of the group for show command.
+func (g *Group) snapshotDetails(ctx context.Context, p provider.Provider, instances []Instance) []provider.Snapshot {
+	var list []provider.Snapshot
+
+	s, ok := p.(provider.Snapshotter)
+	if !ok {
+		return list
+	}
+
+	for _, inst := range instances {
+		if enabled, _ := inst.snapshot(*g); !enabled {
+			continue
+		}
+
+		snapshots, err := s.Snapshots(ctx, inst.Name)
+		if err != nil {
+			fmt.Println("list snapshots:", err)
+			continue
+		}
+
+		if len(snapshots) > 0 {
+			list = append(list, snapshots[0])
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,59 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/marintailor/rcstate/cmd/api/cloudsql"
+)
+
+// sqlState manages the state of Cloud SQL instances in a group,
+// and returns the errors of the failed instances.
+func (g *Group) sqlState(ctx context.Context, s string) error {
+	if len(g.Resource.SQL) == 0 {
+		return nil
+	}
+
+	var errs []error
+
+	insts := cloudsql.NewInstances(g.Project)
+	for _, sql := range g.Resource.SQL {
+		switch s {
+		case "up":
+			opCtx, cancel := g.timeout.context(ctx, timeoutStart)
+			if err := insts.Start(opCtx, sql.Name); err != nil {
+				fmt.Println("sql state up:", err)
+				errs = append(errs, err)
+			}
+			cancel()
+		case "down":
+			opCtx, cancel := g.timeout.context(ctx, timeoutStop)
+			if err := insts.Stop(opCtx, sql.Name); err != nil {
+				fmt.Println("sql state down:", err)
+				errs = append(errs, err)
+			}
+			cancel()
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// GetDetailsSQL will get details about Cloud SQL instances of the group for show command.
+func (g *Group) GetDetailsSQL(ctx context.Context) []cloudsql.Instance {
+	var list []cloudsql.Instance
+
+	insts := cloudsql.NewInstances(g.Project)
+	for _, sql := range g.Resource.SQL {
+		inst, err := insts.Get(ctx, sql.Name)
+		if err != nil {
+			fmt.Println("list sql instances:", err)
+			continue
+		}
+
+		list = append(list, inst)
+	}
+
+	return list
+}
@@ -0,0 +1,137 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/marintailor/rcstate/cmd/api/provider"
+)
+
+// fakeProvider is an in-memory compute provider that records the instances started and stopped.
+type fakeProvider struct {
+	mu          sync.Mutex
+	calls       []string
+	fail        map[string]bool
+	preemptible map[string]bool
+	status      map[string]string
+}
+
+func newFakeProvider(fail ...string) *fakeProvider {
+	f := &fakeProvider{fail: map[string]bool{}, preemptible: map[string]bool{}, status: map[string]string{}}
+	for _, name := range fail {
+		f.fail[name] = true
+	}
+
+	return f
+}
+
+func (f *fakeProvider) ExternalIP(ctx context.Context, name string) (string, error) {
+	return "", nil
+}
+
+func (f *fakeProvider) GetList(ctx context.Context) ([]provider.Instance, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var list []provider.Instance
+	for name, status := range f.status {
+		list = append(list, provider.Instance{Name: name, Status: status, Preemptible: f.preemptible[name]})
+	}
+
+	return list, nil
+}
+
+func (f *fakeProvider) Start(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, "start "+name)
+	if f.fail[name] {
+		return fmt.Errorf("start %s: failed", name)
+	}
+
+	f.status[name] = "RUNNING"
+
+	return nil
+}
+
+func (f *fakeProvider) Status(ctx context.Context, name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if s, ok := f.status[name]; ok {
+		return s, nil
+	}
+
+	return "TERMINATED", nil
+}
+
+func (f *fakeProvider) Stop(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, "stop "+name)
+	f.status[name] = "TERMINATED"
+
+	return nil
+}
+
+// useFakeProvider makes the groups use the fake provider, and keeps the state of the test in a temporary file.
+func useFakeProvider(t *testing.T, f *fakeProvider) {
+	t.Helper()
+
+	t.Setenv("RCSTATE_STATE_FILE", filepath.Join(t.TempDir(), "state.json"))
+
+	computeProvider = func(provider.Options) (provider.Provider, error) {
+		return f, nil
+	}
+
+	t.Cleanup(func() {
+		computeProvider = defaultComputeProvider
+	})
+}
+
+var defaultComputeProvider = computeProvider
+
+// vmGroup returns a group of virtual machines with the instances.
+func vmGroup(name string, dependsOn []string, instances ...Instance) Group {
+	return Group{
+		DependsOn: dependsOn,
+		Name:      name,
+		Resource:  Resource{VM: VM{Instance: instances}},
+	}
+}
+
+func TestState(t *testing.T) {
+	groups := []Group{
+		vmGroup("app", nil, Instance{Name: "web"}, Instance{Name: "api"}),
+		vmGroup("db", nil, Instance{Name: "main"}),
+	}
+
+	tests := []struct {
+		state string
+		want  []string
+	}{
+		{state: "up", want: []string{"start web", "start api", "start main"}},
+		{state: "down", want: []string{"stop web", "stop api", "stop main"}},
+		{state: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			f := newFakeProvider()
+			useFakeProvider(t, f)
+
+			env := Environment{Name: "test", Group: groups}
+			env.State(context.Background(), tt.state)
+
+			if !reflect.DeepEqual(f.calls, tt.want) {
+				t.Errorf("calls = %v, want %v", f.calls, tt.want)
+			}
+		})
+	}
+}
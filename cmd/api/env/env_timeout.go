@@ -0,0 +1,95 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kinds of operations with a timeout.
+const (
+	timeoutDNS      = "dns"
+	timeoutScript   = "script"
+	timeoutSnapshot = "snapshot"
+	timeoutStart    = "start"
+	timeoutStop     = "stop"
+)
+
+// defaultTimeout stores the timeout of each kind of operation, used when no timeout is declared.
+var defaultTimeout = map[string]time.Duration{
+	timeoutDNS:      time.Minute,
+	timeoutScript:   10 * time.Minute,
+	timeoutSnapshot: 30 * time.Minute,
+	timeoutStart:    10 * time.Minute,
+	timeoutStop:     10 * time.Minute,
+}
+
+// value returns the declared timeout of the operation kind.
+func (t *Timeout) value(kind string) *string {
+	switch kind {
+	case timeoutDNS:
+		return &t.DNS
+	case timeoutScript:
+		return &t.Script
+	case timeoutSnapshot:
+		return &t.Snapshot
+	case timeoutStart:
+		return &t.Start
+	case timeoutStop:
+		return &t.Stop
+	default:
+		return nil
+	}
+}
+
+// validate ensures that all declared timeouts are valid durations.
+func (t *Timeout) validate() error {
+	for kind := range defaultTimeout {
+		v := *t.value(kind)
+		if v == "" {
+			continue
+		}
+
+		if _, err := time.ParseDuration(v); err != nil {
+			return fmt.Errorf("timeout %q: %w", kind, err)
+		}
+	}
+
+	return nil
+}
+
+// override sets the timeouts provided in format "kind=duration".
+func (t *Timeout) override(timeouts []string) error {
+	for _, o := range timeouts {
+		kind, d, ok := strings.Cut(o, "=")
+		if !ok {
+			return fmt.Errorf("timeout %q: expected format kind=duration", o)
+		}
+
+		v := t.value(kind)
+		if v == nil {
+			return fmt.Errorf("timeout %q: unknown kind %q", o, kind)
+		}
+
+		*v = d
+	}
+
+	return t.validate()
+}
+
+// duration returns the declared timeout of the operation kind, or the default timeout.
+func (t *Timeout) duration(kind string) time.Duration {
+	if v := t.value(kind); v != nil && *v != "" {
+		if d, err := time.ParseDuration(*v); err == nil {
+			return d
+		}
+	}
+
+	return defaultTimeout[kind]
+}
+
+// context returns a context that is canceled after the timeout of the operation kind.
+func (t *Timeout) context(ctx context.Context, kind string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, t.duration(kind))
+}
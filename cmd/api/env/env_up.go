@@ -1,18 +1,21 @@
 package env
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // Up will turn up one or all environments based on provided config..
-func (c *Config) Up() (string, error) {
+func (c *Config) Up(ctx context.Context) (string, error) {
 	if c.Name != "" {
-		return c.UpSingle()
+		return c.UpSingle(ctx)
 	}
 
-	return c.UpAll()
+	return c.UpAll(ctx)
 }
 
 // UpSingle will turn up an environment.
-func (c *Config) UpSingle() (string, error) {
+func (c *Config) UpSingle(ctx context.Context) (string, error) {
 	data, err := c.GetData()
 	if err != nil {
 		return "", fmt.Errorf("marshal env: %w", err)
@@ -32,13 +35,13 @@ func (c *Config) UpSingle() (string, error) {
 		return fmt.Sprintf("{ \"error\": \"environment %q with label %q not found\"}", c.Name, c.Label), nil
 	}
 
-	env.State("up")
+	env.State(ctx, "up")
 
 	return "{ \"status\": \"success\" }", nil
 }
 
 // UpAll will turn up all environments.
-func (c *Config) UpAll() (string, error) {
+func (c *Config) UpAll(ctx context.Context) (string, error) {
 	data, err := c.GetData()
 	if err != nil {
 		return "", fmt.Errorf("marshal env: %w", err)
@@ -51,7 +54,7 @@ func (c *Config) UpAll() (string, error) {
 
 	for _, env := range e.Envs {
 		if env.CheckLabel(c.Label) {
-			env.State("up")
+			env.State(ctx, "up")
 		}
 	}
 
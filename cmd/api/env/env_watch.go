@@ -0,0 +1,235 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/marintailor/rcstate/cmd/api/provider"
+	"github.com/marintailor/rcstate/cmd/api/state"
+)
+
+// restart stores the failed restarts of a preempted instance.
+// A started instance that failed to come into Up state is retried without starting it again.
+type restart struct {
+	failures int
+	next     time.Time
+	started  bool
+}
+
+// watcher restarts preempted instances of active environments.
+type watcher struct {
+	interval   time.Duration
+	maxRetries int
+	restarts   map[string]*restart
+}
+
+// activeKey returns the key that marks an environment as active.
+func activeKey(name string) string {
+	return fmt.Sprintf("env/%s/active", name)
+}
+
+// markActive records that the environment is up, or removes the record when it is down.
+func (env *Environment) markActive(s string) {
+	var err error
+
+	switch s {
+	case "up":
+		err = state.Set(activeKey(env.Name), time.Now().UTC().Format(time.RFC3339))
+	case "down":
+		err = state.Delete(activeKey(env.Name))
+	}
+
+	if err != nil {
+		fmt.Printf("environment %q: mark %s: %s\n", env.Name, s, err)
+	}
+}
+
+// active returns true if the environment was brought up and not brought down since.
+func (env *Environment) active() (bool, error) {
+	_, ok, err := state.Get(activeKey(env.Name))
+	return ok, err
+}
+
+// Watch checks active environments every interval, and restarts their preempted instances.
+// It runs until the context is done.
+func (c *Config) Watch(ctx context.Context) error {
+	data, err := c.GetData()
+	if err != nil {
+		return fmt.Errorf("marshal env: %w", err)
+	}
+
+	e, err := NewEnvironments(string(data))
+	if err != nil {
+		return fmt.Errorf("new environment: %w", err)
+	}
+
+	if c.Interval <= 0 {
+		return fmt.Errorf("interval must be greater than zero")
+	}
+
+	w := watcher{
+		interval:   c.Interval,
+		maxRetries: c.MaxRetries,
+		restarts:   map[string]*restart{},
+	}
+
+	for {
+		for _, env := range e.Envs {
+			if c.Name != "" && env.Name != c.Name {
+				continue
+			}
+
+			if !env.CheckLabel(c.Label) {
+				continue
+			}
+
+			w.environment(ctx, env)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(c.Interval):
+		}
+	}
+}
+
+// environment restarts the preempted instances of an active environment.
+func (w *watcher) environment(ctx context.Context, env Environment) {
+	ok, err := env.active()
+	if err != nil {
+		fmt.Printf("watch environment %q: %s\n", env.Name, err)
+		return
+	}
+
+	if !ok {
+		return
+	}
+
+	for _, g := range env.Group {
+		if len(g.Resource.VM.Instance) == 0 && g.Resource.VM.Selector.empty() {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		g.timeout = env.Timeout
+
+		if err := w.group(ctx, env.Name, g); err != nil {
+			fmt.Printf("watch environment %q: group %q: %s\n", env.Name, g.Name, err)
+		}
+	}
+}
+
+// group restarts the preempted instances of a group.
+func (w *watcher) group(ctx context.Context, envName string, g Group) error {
+	p, err := g.newProvider()
+	if err != nil {
+		return err
+	}
+
+	list, err := p.GetList(ctx)
+	if err != nil {
+		return fmt.Errorf("list instances: %w", err)
+	}
+
+	details := byName(list)
+
+	instances, err := g.resolveInstances(list)
+	if err != nil {
+		return err
+	}
+
+	for _, instance := range instances {
+		d, ok := details[instance.Name]
+		if !ok || !d.Preemptible {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%s", envName, g.Name, instance.Name)
+
+		r, restarting := w.restarts[key]
+
+		if d.Status == "RUNNING" {
+			if restarting && r.started {
+				w.restart(ctx, p, g, instance, key)
+				continue
+			}
+
+			delete(w.restarts, key)
+			continue
+		}
+
+		if d.Status != "TERMINATED" && d.Status != "STOPPED" {
+			continue
+		}
+
+		if restarting {
+			r.started = false
+		}
+
+		w.restart(ctx, p, g, instance, key)
+	}
+
+	return nil
+}
+
+// restart starts a preempted instance, and brings it into Up state.
+// Failed restarts are retried with exponential backoff up to the maximum number of retries.
+// An instance that started but failed to come into Up state stays in backoff, and only its Up state is retried.
+func (w *watcher) restart(ctx context.Context, p provider.Provider, g Group, instance Instance, key string) {
+	r, ok := w.restarts[key]
+	if !ok {
+		r = &restart{}
+		w.restarts[key] = r
+	}
+
+	if r.failures > w.maxRetries {
+		return
+	}
+
+	if time.Now().Before(r.next) {
+		return
+	}
+
+	if !r.started {
+		fmt.Printf("watch: instance %q was preempted, restarting\n", instance.Name)
+
+		startCtx, cancel := g.timeout.context(ctx, timeoutStart)
+		err := startInstance(startCtx, p, instance.Name)
+		cancel()
+
+		if err != nil {
+			w.fail(r, instance.Name, "restart", err)
+			return
+		}
+
+		r.started = true
+	} else {
+		fmt.Printf("watch: instance %q was restarted, retrying up state\n", instance.Name)
+	}
+
+	if err := instanceUp(ctx, p, g, instance, os.Stdout); err != nil {
+		w.fail(r, instance.Name, "up state", err)
+		return
+	}
+
+	delete(w.restarts, key)
+}
+
+// fail records a failed restart of an instance, and reports when it is retried, or that it is given up.
+func (w *watcher) fail(r *restart, name string, step string, err error) {
+	r.failures++
+	r.next = time.Now().Add(w.interval * time.Duration(1<<r.failures))
+
+	if r.failures > w.maxRetries {
+		fmt.Printf("watch: instance %q: restart failed %d times, giving up: %s\n", name, r.failures, err)
+		return
+	}
+
+	fmt.Printf("watch: instance %q: %s: %s, retrying after %s\n", name, step, err, r.next.Format(time.RFC3339))
+}
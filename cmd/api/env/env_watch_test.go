@@ -0,0 +1,62 @@
+package env
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestWatcherRestart(t *testing.T) {
+	tests := []struct {
+		name     string
+		instance Instance
+		calls    []string
+		failures []int
+	}{
+		{
+			name:     "restarted",
+			instance: Instance{Name: "web"},
+			calls:    []string{"start web"},
+			failures: []int{0, 0, 0},
+		},
+		{
+			name: "up state failed",
+			// The DNS provider of the record is not configured, so the up state fails.
+			instance: Instance{Name: "web", Record: Record{Domain: "example.com", Zone: "www.example.com", Type: "A", Provider: "webhook"}},
+			calls:    []string{"start web"},
+			failures: []int{1, 2, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeProvider()
+			f.status["web"] = "TERMINATED"
+			f.preemptible["web"] = true
+			useFakeProvider(t, f)
+
+			w := watcher{maxRetries: 1, restarts: map[string]*restart{}}
+			g := vmGroup("app", nil, tt.instance)
+
+			// The instance is checked again after each restart, without waiting for the backoff.
+			for i, want := range tt.failures {
+				if err := w.group(context.Background(), "test", g); err != nil {
+					t.Fatalf("group: %s", err)
+				}
+
+				var failures int
+				if r, ok := w.restarts["test/app/web"]; ok {
+					failures = r.failures
+				}
+
+				if failures != want {
+					t.Errorf("check %d: failures = %d, want %d", i+1, failures, want)
+				}
+			}
+
+			if !reflect.DeepEqual(f.calls, tt.calls) {
+				t.Errorf("calls = %v, want %v", f.calls, tt.calls)
+			}
+		})
+	}
+}
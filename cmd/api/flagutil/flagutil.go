@@ -0,0 +1,18 @@
+// Package flagutil implements flag values shared by the commands.
+package flagutil
+
+import "strings"
+
+// StringList is a flag value that collects the values of a repeated flag.
+type StringList []string
+
+// String returns the values of the flag separated by comma.
+func (s *StringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+// Set adds a value of the flag.
+func (s *StringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
@@ -0,0 +1,104 @@
+package gce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	compute "cloud.google.com/go/compute/apiv1"
+	"golang.org/x/oauth2"
+	auth "golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+)
+
+// scope is the OAuth scope requested for the Compute Engine API.
+const scope = "https://www.googleapis.com/auth/cloud-platform"
+
+// Client stores long-lived Compute Engine API clients that share one cached token source.
+// A Client is safe for concurrent use.
+type Client struct {
+	disks     *compute.DisksClient
+	groups    *compute.InstanceGroupManagersClient
+	instances *compute.InstancesClient
+	snapshots *compute.SnapshotsClient
+}
+
+var (
+	defaultMu     sync.Mutex
+	defaultClient *Client
+)
+
+// NewClient returns a Client authorized with the application default credentials.
+// The access token is fetched once, and refreshed only when it expires.
+func NewClient(ctx context.Context) (*Client, error) {
+	ts, err := auth.DefaultTokenSource(ctx, scope)
+	if err != nil {
+		return nil, fmt.Errorf("default token source: %w", err)
+	}
+
+	opt := option.WithTokenSource(oauth2.ReuseTokenSource(nil, ts))
+
+	c := &Client{}
+
+	if c.disks, err = compute.NewDisksRESTClient(ctx, opt); err != nil {
+		return nil, fmt.Errorf("NewDisksRESTClient: %w", err)
+	}
+
+	if c.groups, err = compute.NewInstanceGroupManagersRESTClient(ctx, opt); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("NewInstanceGroupManagersRESTClient: %w", err)
+	}
+
+	if c.instances, err = compute.NewInstancesRESTClient(ctx, opt); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("NewInstancesRESTClient: %w", err)
+	}
+
+	if c.snapshots, err = compute.NewSnapshotsRESTClient(ctx, opt); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("NewSnapshotsRESTClient: %w", err)
+	}
+
+	return c, nil
+}
+
+// DefaultClient returns the Client shared by the process, created on first use.
+// Only a successfully created Client is kept, so the creation is retried after an error.
+func DefaultClient() (*Client, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultClient != nil {
+		return defaultClient, nil
+	}
+
+	c, err := NewClient(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	defaultClient = c
+
+	return defaultClient, nil
+}
+
+// Close closes the API clients.
+func (c *Client) Close() error {
+	var errs []error
+
+	if c.disks != nil {
+		errs = append(errs, c.disks.Close())
+	}
+	if c.groups != nil {
+		errs = append(errs, c.groups.Close())
+	}
+	if c.instances != nil {
+		errs = append(errs, c.instances.Close())
+	}
+	if c.snapshots != nil {
+		errs = append(errs, c.snapshots.Close())
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,53 @@
+package gce
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/compute/apiv1/computepb"
+)
+
+// GuestAttribute returns the value of the guest attribute in format "namespace/key".
+func (i *Instances) GuestAttribute(ctx context.Context, inst string, key string) (string, error) {
+	c, err := DefaultClient()
+	if err != nil {
+		return "", err
+	}
+
+	req := &computepb.GetGuestAttributesInstanceRequest{
+		Project:     i.Project,
+		Zone:        i.Zone,
+		Instance:    inst,
+		VariableKey: &key,
+	}
+
+	resp, err := c.instances.GetGuestAttributes(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("get guest attribute %q of instance %q: %w", key, inst, err)
+	}
+
+	return resp.GetVariableValue(), nil
+}
+
+// SerialOutput returns the recent output of the first serial port of the instance.
+func (i *Instances) SerialOutput(ctx context.Context, inst string) (string, error) {
+	c, err := DefaultClient()
+	if err != nil {
+		return "", err
+	}
+
+	port := int32(1)
+	req := &computepb.GetSerialPortOutputInstanceRequest{
+		Project:  i.Project,
+		Zone:     i.Zone,
+		Instance: inst,
+		Port:     &port,
+	}
+
+	resp, err := c.instances.GetSerialPortOutput(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("get serial port output of instance %q: %w", inst, err)
+	}
+
+	return resp.GetContents(), nil
+}
@@ -0,0 +1,140 @@
+package gce
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	compute "cloud.google.com/go/compute/apiv1"
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"google.golang.org/api/iterator"
+)
+
+// InstanceGroup stores details of a managed instance group.
+type InstanceGroup struct {
+	Name        string `json:"name"`
+	CurrentSize int    `json:"current_size"`
+	TargetSize  int    `json:"target_size"`
+}
+
+// InstanceGroups stores managed instance groups in specific project and zone.
+type InstanceGroups struct {
+	Project string
+	Zone    string
+}
+
+// NewInstanceGroups returns an InstanceGroups struct with provided project and zone.
+func NewInstanceGroups(project string, zone string) *InstanceGroups {
+	return &InstanceGroups{
+		Project: project,
+		Zone:    zone,
+	}
+}
+
+// Get returns the target size and the number of running instances of a managed instance group.
+func (ig *InstanceGroups) Get(ctx context.Context, name string) (InstanceGroup, error) {
+	c, err := DefaultClient()
+	if err != nil {
+		return InstanceGroup{}, err
+	}
+
+	req := &computepb.GetInstanceGroupManagerRequest{
+		Project:              ig.Project,
+		Zone:                 ig.Zone,
+		InstanceGroupManager: name,
+	}
+
+	mig, err := c.groups.Get(ctx, req)
+	if err != nil {
+		return InstanceGroup{}, fmt.Errorf("get instance group %q: %w", name, err)
+	}
+
+	running, err := ig.running(ctx, c.groups, name)
+	if err != nil {
+		return InstanceGroup{}, err
+	}
+
+	return InstanceGroup{
+		Name:        name,
+		CurrentSize: running,
+		TargetSize:  int(mig.GetTargetSize()),
+	}, nil
+}
+
+// running returns the number of running instances in a managed instance group.
+func (ig *InstanceGroups) running(ctx context.Context, c *compute.InstanceGroupManagersClient, name string) (int, error) {
+	req := &computepb.ListManagedInstancesInstanceGroupManagersRequest{
+		Project:              ig.Project,
+		Zone:                 ig.Zone,
+		InstanceGroupManager: name,
+	}
+
+	var count int
+
+	it := c.ListManagedInstances(ctx, req)
+	for {
+		inst, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("iterate managed instances: %w", err)
+		}
+
+		if inst.GetInstanceStatus() == "RUNNING" {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Resize will set the target size of a managed instance group, and wait until the operation is done.
+func (ig *InstanceGroups) Resize(ctx context.Context, name string, size int) error {
+	c, err := DefaultClient()
+	if err != nil {
+		return err
+	}
+
+	req := &computepb.ResizeInstanceGroupManagerRequest{
+		Project:              ig.Project,
+		Zone:                 ig.Zone,
+		InstanceGroupManager: name,
+		Size:                 int32(size),
+	}
+
+	op, err := c.groups.Resize(ctx, req)
+	if err != nil {
+		return fmt.Errorf("resize instance group: %w", err)
+	}
+
+	if err = op.Wait(ctx); err != nil {
+		return fmt.Errorf("wait operation: %w", err)
+	}
+
+	return nil
+}
+
+// WaitRunning waits until the number of running instances in a managed instance group reaches the size,
+// or the context is done.
+func (ig *InstanceGroups) WaitRunning(ctx context.Context, name string, size int) error {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		mig, err := ig.Get(ctx, name)
+		if err != nil {
+			return fmt.Errorf("wait instance group: %w", err)
+		}
+
+		if mig.CurrentSize >= size {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait instance group %q: %w", name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package gce
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	compute "cloud.google.com/go/compute/apiv1"
+	"cloud.google.com/go/compute/apiv1/computepb"
+	"github.com/marintailor/rcstate/cmd/api/provider"
+	"google.golang.org/api/iterator"
+)
+
+// snapshotLabel is the label set on snapshots created by rcstate.
+const snapshotLabel = "created-by"
+
+// Snapshot creates a snapshot of each disk attached to the instance,
+// and deletes the older snapshots created by rcstate beyond the retention count per disk.
+func (i *Instances) Snapshot(ctx context.Context, inst string, retention int) error {
+	c, err := DefaultClient()
+	if err != nil {
+		return err
+	}
+
+	resp, err := i.get(ctx, inst)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range resp.GetDisks() {
+		disk := lastSegment(d.GetSource())
+		if err := i.createSnapshot(ctx, c.disks, inst, disk); err != nil {
+			return err
+		}
+
+		if err := i.pruneSnapshots(ctx, c.snapshots, inst, disk, retention); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createSnapshot creates a snapshot of the disk labeled with the instance name.
+func (i *Instances) createSnapshot(ctx context.Context, c *compute.DisksClient, inst string, disk string) error {
+	prefix := disk
+	if len(prefix) > 47 {
+		prefix = strings.TrimRight(prefix[:47], "-")
+	}
+	name := fmt.Sprintf("%s-%s", prefix, time.Now().UTC().Format("20060102-150405"))
+
+	req := &computepb.CreateSnapshotDiskRequest{
+		Project: i.Project,
+		Zone:    i.Zone,
+		Disk:    disk,
+		SnapshotResource: &computepb.Snapshot{
+			Name: &name,
+			Labels: map[string]string{
+				snapshotLabel: "rcstate",
+				"instance":    inst,
+				"disk":        disk,
+			},
+		},
+	}
+
+	op, err := c.CreateSnapshot(ctx, req)
+	if err != nil {
+		return fmt.Errorf("create snapshot of disk %q: %w", disk, err)
+	}
+
+	if err = op.Wait(ctx); err != nil {
+		return fmt.Errorf("wait operation: %w", err)
+	}
+
+	return nil
+}
+
+// pruneSnapshots deletes the snapshots of the disk created by rcstate, except the newest ones.
+func (i *Instances) pruneSnapshots(ctx context.Context, c *compute.SnapshotsClient, inst string, disk string, retention int) error {
+	list, err := i.Snapshots(ctx, inst)
+	if err != nil {
+		return err
+	}
+
+	var snapshots []provider.Snapshot
+	for _, s := range list {
+		if s.Disk == disk {
+			snapshots = append(snapshots, s)
+		}
+	}
+
+	if len(snapshots) <= retention {
+		return nil
+	}
+
+	for _, s := range snapshots[retention:] {
+		req := &computepb.DeleteSnapshotRequest{
+			Project:  i.Project,
+			Snapshot: s.Name,
+		}
+
+		op, err := c.Delete(ctx, req)
+		if err != nil {
+			return fmt.Errorf("delete snapshot %q: %w", s.Name, err)
+		}
+
+		if err = op.Wait(ctx); err != nil {
+			return fmt.Errorf("wait operation: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Snapshots returns the snapshots of the instance's disks created by rcstate, newest first.
+func (i *Instances) Snapshots(ctx context.Context, inst string) ([]provider.Snapshot, error) {
+	c, err := DefaultClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// Multiple expressions of a list filter are parenthesized, and are all required to match.
+	filter := fmt.Sprintf("(labels.%s = %q) (labels.instance = %q)", snapshotLabel, "rcstate", inst)
+
+	req := &computepb.ListSnapshotsRequest{
+		Project: i.Project,
+		Filter:  &filter,
+	}
+
+	var list []provider.Snapshot
+
+	it := c.snapshots.List(ctx, req)
+	for {
+		s, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("iterate snapshots: %w", err)
+		}
+
+		list = append(list, provider.Snapshot{
+			Instance: inst,
+			Disk:     lastSegment(s.GetSourceDisk()),
+			Name:     s.GetName(),
+			Created:  s.GetCreationTimestamp(),
+			Status:   s.GetStatus(),
+		})
+	}
+
+	sort.Slice(list, func(a, b int) bool {
+		ta, _ := time.Parse(time.RFC3339, list[a].Created)
+		tb, _ := time.Parse(time.RFC3339, list[b].Created)
+		return ta.After(tb)
+	})
+
+	return list, nil
+}
+
+// lastSegment returns the last segment of a resource URL.
+func lastSegment(url string) string {
+	parts := strings.Split(url, "/")
+	return parts[len(parts)-1]
+}
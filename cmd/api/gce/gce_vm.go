@@ -4,33 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
+	"sync"
 
-	compute "cloud.google.com/go/compute/apiv1"
 	"cloud.google.com/go/compute/apiv1/computepb"
-	"golang.org/x/oauth2"
-	auth "golang.org/x/oauth2/google"
-	compute_engine "google.golang.org/api/compute/v1"
+	"github.com/marintailor/rcstate/cmd/api/provider"
 	"google.golang.org/api/iterator"
 )
 
-// Instance stores details of an instance.
-type Instance struct {
-	Name        string `json:"name"`
-	Status      string `json:"status"`
-	Internal    string `json:"internal"`
-	External    string `json:"external"`
-	Type        string `json:"type"`
-	Preemptible bool   `json:"preemptible"`
-}
-
 // Instances stores list of instances in specific project and zone.
+// It implements the provider.Provider interface for Google Compute Engine.
+//
+// The instances returned by the last list call are cached, and status, external IP,
+// and machine type lookups are served from the cache until the instance is changed.
 type Instances struct {
-	List    []Instance
+	List    []provider.Instance
 	Project string
 	Zone    string
+
+	mu    sync.Mutex
+	cache map[string]*computepb.Instance
 }
 
 // NewInstances returns an Instances struct with provided project and zone.
@@ -42,109 +34,170 @@ func NewInstances(project string, zone string) *Instances {
 }
 
 // GetInstancesList returns a JSON formatted string with instances.
-func (i *Instances) GetInstancesList() (string, error) {
-	ctx := context.Background()
-	instancesClient, err := compute.NewInstancesRESTClient(ctx)
+func (i *Instances) GetInstancesList(ctx context.Context) (string, error) {
+	list, err := i.GetList(ctx)
+	if err != nil {
+		return "", err
+	}
 
+	j, err := json.Marshal(list)
 	if err != nil {
-		return "", fmt.Errorf("NewInstancesRESTClient: %w", err)
+		return "", fmt.Errorf("marshal instances list: %w", err)
+	}
+
+	return string(j), nil
+}
+
+// GetList returns a slice of Instance, and caches the listed instances.
+func (i *Instances) GetList(ctx context.Context) ([]provider.Instance, error) {
+	c, err := DefaultClient()
+	if err != nil {
+		return []provider.Instance{}, err
 	}
-	defer instancesClient.Close()
 
 	req := &computepb.ListInstancesRequest{
 		Project: i.Project,
 		Zone:    i.Zone,
 	}
 
-	it := instancesClient.List(ctx, req)
+	i.List = nil
+	cache := map[string]*computepb.Instance{}
+
+	it := c.instances.List(ctx, req)
 	for {
 		inst, err := it.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return "", fmt.Errorf("iterate instances: %w", err)
+			return []provider.Instance{}, fmt.Errorf("iterate instances: %w", err)
 		}
 
-		d := getInstanceDetails(inst)
-		i.addInstance(d)
+		cache[inst.GetName()] = inst
+		i.addInstance(getInstanceDetails(inst))
 	}
 
-	j, err := json.Marshal(i.List)
-	if err != nil {
-		return "", fmt.Errorf("marshal instances list: %w", err)
-	}
+	i.mu.Lock()
+	i.cache = cache
+	i.mu.Unlock()
 
-	return string(j), nil
+	return i.List, nil
 }
 
-// GetList returns a slice of Instance.
-func (i *Instances) GetList() ([]Instance, error) {
-	ctx := context.Background()
-	instancesClient, err := compute.NewInstancesRESTClient(ctx)
-
+// GetAggregatedList returns a slice of Instance from all zones of the project.
+func (i *Instances) GetAggregatedList(ctx context.Context) ([]provider.Instance, error) {
+	c, err := DefaultClient()
 	if err != nil {
-		return []Instance{}, fmt.Errorf("NewInstancesRESTClient: %w", err)
+		return []provider.Instance{}, err
 	}
-	defer instancesClient.Close()
 
-	req := &computepb.ListInstancesRequest{
+	req := &computepb.AggregatedListInstancesRequest{
 		Project: i.Project,
-		Zone:    i.Zone,
 	}
 
-	it := instancesClient.List(ctx, req)
+	i.List = nil
+
+	it := c.instances.AggregatedList(ctx, req)
 	for {
-		inst, err := it.Next()
+		pair, err := it.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return []Instance{}, fmt.Errorf("iterate instances: %w", err)
+			return []provider.Instance{}, fmt.Errorf("iterate aggregated instances: %w", err)
 		}
 
-		d := getInstanceDetails(inst)
-		i.addInstance(d)
+		for _, inst := range pair.Value.GetInstances() {
+			i.addInstance(getInstanceDetails(inst))
+		}
 	}
 
 	return i.List, nil
 }
 
 // getInstanceDetails returns a Instance struct with instance's details.
-func getInstanceDetails(inst *computepb.Instance) Instance {
+func getInstanceDetails(inst *computepb.Instance) provider.Instance {
 	network := inst.GetNetworkInterfaces()
 	schedule := inst.GetScheduling()
 
-	externalIP := ""
-	if inst.GetStatus() == "RUNNING" {
-		externalIP = *network[0].AccessConfigs[0].NatIP
+	var internalIP, externalIP string
+	if len(network) > 0 {
+		internalIP = network[0].GetNetworkIP()
+		if inst.GetStatus() == "RUNNING" {
+			externalIP = natIP(inst)
+		}
 	}
 
-	vmType := strings.Split(inst.GetMachineType(), "/")[len(strings.Split(inst.GetMachineType(), "/"))-1]
-
-	return Instance{
+	return provider.Instance{
 		Name:        inst.GetName(),
 		Status:      inst.GetStatus(),
-		Internal:    *network[0].NetworkIP,
+		Internal:    internalIP,
 		External:    externalIP,
-		Type:        vmType,
-		Preemptible: *schedule.Preemptible,
+		Type:        lastSegment(inst.GetMachineType()),
+		Preemptible: schedule.GetPreemptible() || schedule.GetProvisioningModel() == "SPOT",
+		Zone:        lastSegment(inst.GetZone()),
+		Labels:      inst.GetLabels(),
 	}
 }
 
+// natIP returns the NAT IP address of the first network interface of the instance.
+func natIP(inst *computepb.Instance) string {
+	network := inst.GetNetworkInterfaces()
+	if len(network) == 0 || len(network[0].GetAccessConfigs()) == 0 {
+		return ""
+	}
+
+	return network[0].GetAccessConfigs()[0].GetNatIP()
+}
+
 // addInstance will add an instance to the instances list.
-func (i *Instances) addInstance(inst Instance) {
+func (i *Instances) addInstance(inst provider.Instance) {
 	i.List = append(i.List, inst)
 }
 
-// Start will start an instance.
-func (i *Instances) Start(inst string) error {
-	ctx := context.Background()
-	instancesClient, err := compute.NewInstancesRESTClient(ctx)
+// get returns the instance from the cache of the last list call, or from the API.
+func (i *Instances) get(ctx context.Context, inst string) (*computepb.Instance, error) {
+	i.mu.Lock()
+	cached, ok := i.cache[inst]
+	i.mu.Unlock()
+
+	if ok {
+		return cached, nil
+	}
+
+	c, err := DefaultClient()
 	if err != nil {
-		return fmt.Errorf("NewInstancesRESTClient: %w", err)
+		return nil, err
 	}
-	defer instancesClient.Close()
+
+	req := &computepb.GetInstanceRequest{
+		Project:  i.Project,
+		Zone:     i.Zone,
+		Instance: inst,
+	}
+
+	resp, err := c.instances.Get(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("get instance %q: %w", inst, err)
+	}
+
+	return resp, nil
+}
+
+// forget removes the instance from the cache, after it is changed.
+func (i *Instances) forget(inst string) {
+	i.mu.Lock()
+	delete(i.cache, inst)
+	i.mu.Unlock()
+}
+
+// Start will start an instance, and wait until the operation is done.
+func (i *Instances) Start(ctx context.Context, inst string) error {
+	c, err := DefaultClient()
+	if err != nil {
+		return err
+	}
+	defer i.forget(inst)
 
 	req := &computepb.StartInstanceRequest{
 		Project:  i.Project,
@@ -152,7 +205,7 @@ func (i *Instances) Start(inst string) error {
 		Instance: inst,
 	}
 
-	op, err := instancesClient.Start(ctx, req)
+	op, err := c.instances.Start(ctx, req)
 	if err != nil {
 		return fmt.Errorf("start instance: %w", err)
 	}
@@ -165,29 +218,22 @@ func (i *Instances) Start(inst string) error {
 }
 
 // Status returns the status of the instance.
-func (i *Instances) Status(inst string) (string, error) {
-	ctx := context.Background()
-	computeService, err := compute_engine.NewService(ctx)
-	if err != nil {
-		return "", fmt.Errorf("get status instance %q: %w", inst, err)
-	}
-
-	resp, err := computeService.Instances.Get(i.Project, i.Zone, inst).Context(ctx).Do()
+func (i *Instances) Status(ctx context.Context, inst string) (string, error) {
+	resp, err := i.get(ctx, inst)
 	if err != nil {
 		return "", fmt.Errorf("get status instance %q: %w", inst, err)
 	}
 
-	return resp.Status, nil
+	return resp.GetStatus(), nil
 }
 
-// Stop will stop the instance.
-func (i *Instances) Stop(inst string) error {
-	ctx := context.Background()
-	instancesClient, err := compute.NewInstancesRESTClient(ctx)
+// Stop will stop the instance, and wait until the operation is done.
+func (i *Instances) Stop(ctx context.Context, inst string) error {
+	c, err := DefaultClient()
 	if err != nil {
-		return fmt.Errorf("NewInstancesRESTClient: %w", err)
+		return err
 	}
-	defer instancesClient.Close()
+	defer i.forget(inst)
 
 	req := &computepb.StopInstanceRequest{
 		Project:  i.Project,
@@ -195,7 +241,7 @@ func (i *Instances) Stop(inst string) error {
 		Instance: inst,
 	}
 
-	op, err := instancesClient.Stop(ctx, req)
+	op, err := c.instances.Stop(ctx, req)
 	if err != nil {
 		return fmt.Errorf("stop instance: %w", err)
 	}
@@ -207,68 +253,107 @@ func (i *Instances) Stop(inst string) error {
 	return nil
 }
 
-// GetInstanceExternalIP returns the external IP address of the instance in specific project and zone.
-func GetInstanceExternalIP(inst string, project string, zone string) (string, error) {
-	url := fmt.Sprintf("https://compute.googleapis.com/compute/v1/projects/%s/zones/%s/instances/%s", project, zone, inst)
-	token := getToken()
-
-	req, err := http.NewRequest("GET", url, nil)
+// Suspend will suspend the instance, and wait until the operation is done.
+func (i *Instances) Suspend(ctx context.Context, inst string) error {
+	c, err := DefaultClient()
 	if err != nil {
-		return "", fmt.Errorf("new request: %w", err)
+		return err
 	}
-	req.Header.Add("Authorization", "Bearer "+token)
+	defer i.forget(inst)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("client response: %w", err)
+	req := &computepb.SuspendInstanceRequest{
+		Project:  i.Project,
+		Zone:     i.Zone,
+		Instance: inst,
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	op, err := c.instances.Suspend(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("read response body: %w", err)
+		return fmt.Errorf("suspend instance: %w", err)
+	}
+
+	if err = op.Wait(ctx); err != nil {
+		return fmt.Errorf("wait operation: %w", err)
 	}
 
-	return getNATIP(body), nil
+	return nil
 }
 
-// getToken returns an API token.
-func getToken() string {
-	var token *oauth2.Token
-	ctx := context.Background()
-	scopes := []string{
-		"https://www.googleapis.com/auth/cloud-platform",
+// Resume will resume a suspended instance, and wait until the operation is done.
+func (i *Instances) Resume(ctx context.Context, inst string) error {
+	c, err := DefaultClient()
+	if err != nil {
+		return err
 	}
-	credentials, err := auth.FindDefaultCredentials(ctx, scopes...)
-	if err == nil {
-		token, err = credentials.TokenSource.Token()
-		if err != nil {
-			fmt.Print(err)
-		}
+	defer i.forget(inst)
+
+	req := &computepb.ResumeInstanceRequest{
+		Project:  i.Project,
+		Zone:     i.Zone,
+		Instance: inst,
 	}
 
-	return token.AccessToken
-}
+	op, err := c.instances.Resume(ctx, req)
+	if err != nil {
+		return fmt.Errorf("resume instance: %w", err)
+	}
 
-type InstanceDetails struct {
-	NIC []Interface `json:"networkInterfaces"`
+	if err = op.Wait(ctx); err != nil {
+		return fmt.Errorf("wait operation: %w", err)
+	}
+
+	return nil
 }
 
-type Interface struct {
-	AccessConfig []Config `json:"accessConfigs"`
+// MachineType returns the machine type of the instance.
+func (i *Instances) MachineType(ctx context.Context, inst string) (string, error) {
+	resp, err := i.get(ctx, inst)
+	if err != nil {
+		return "", err
+	}
+
+	return lastSegment(resp.GetMachineType()), nil
 }
 
-type Config struct {
-	NATIP string `json:"natIP"`
+// SetMachineType changes the machine type of a stopped instance,
+// and waits until the operation is done.
+func (i *Instances) SetMachineType(ctx context.Context, inst string, machineType string) error {
+	c, err := DefaultClient()
+	if err != nil {
+		return err
+	}
+	defer i.forget(inst)
+
+	mt := fmt.Sprintf("zones/%s/machineTypes/%s", i.Zone, machineType)
+
+	req := &computepb.SetMachineTypeInstanceRequest{
+		Project:  i.Project,
+		Zone:     i.Zone,
+		Instance: inst,
+		InstancesSetMachineTypeRequestResource: &computepb.InstancesSetMachineTypeRequest{
+			MachineType: &mt,
+		},
+	}
+
+	op, err := c.instances.SetMachineType(ctx, req)
+	if err != nil {
+		return fmt.Errorf("set machine type: %w", err)
+	}
+
+	if err = op.Wait(ctx); err != nil {
+		return fmt.Errorf("wait operation: %w", err)
+	}
+
+	return nil
 }
 
-// getNATIP returns the NAT IP address.
-func getNATIP(b []byte) string {
-	var data InstanceDetails
-	if err := json.Unmarshal(b, &data); err != nil {
-		fmt.Println("unmarshal instance details:", err)
-		return ""
+// ExternalIP returns the external IP address of the instance,
+// or an empty string if the instance does not have one.
+func (i *Instances) ExternalIP(ctx context.Context, inst string) (string, error) {
+	resp, err := i.get(ctx, inst)
+	if err != nil {
+		return "", fmt.Errorf("external ip: %w", err)
 	}
-	return data.NIC[0].AccessConfig[0].NATIP
+
+	return natIP(resp), nil
 }
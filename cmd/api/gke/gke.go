@@ -0,0 +1,169 @@
+// Package gke implements functions to manage the size of Google Kubernetes Engine node pools.
+package gke
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/marintailor/rcstate/cmd/api/gce"
+	container "google.golang.org/api/container/v1"
+)
+
+// NodePool stores details of a node pool.
+type NodePool struct {
+	Cluster     string `json:"cluster"`
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	NodeCount   int    `json:"node_count"`
+	Autoscaling bool   `json:"autoscaling"`
+	MinNodes    int    `json:"min_nodes"`
+	MaxNodes    int    `json:"max_nodes"`
+}
+
+// Cluster stores details to manage node pools of a cluster in specific project and location.
+type Cluster struct {
+	Location string
+	Name     string
+	Project  string
+}
+
+// NewCluster returns a Cluster struct with provided project, location and cluster name.
+func NewCluster(project string, location string, name string) *Cluster {
+	return &Cluster{
+		Location: location,
+		Name:     name,
+		Project:  project,
+	}
+}
+
+// nodePoolName returns the full resource name of a node pool.
+func (c *Cluster) nodePoolName(pool string) string {
+	return fmt.Sprintf("projects/%s/locations/%s/clusters/%s/nodePools/%s", c.Project, c.Location, c.Name, pool)
+}
+
+// GetNodePool returns the details of a node pool.
+// The node count is the target size per zone of the node pool's instance groups.
+func (c *Cluster) GetNodePool(ctx context.Context, pool string) (NodePool, error) {
+	svc, err := container.NewService(ctx)
+	if err != nil {
+		return NodePool{}, fmt.Errorf("new container service: %w", err)
+	}
+
+	np, err := svc.Projects.Locations.Clusters.NodePools.Get(c.nodePoolName(pool)).Context(ctx).Do()
+	if err != nil {
+		return NodePool{}, fmt.Errorf("get node pool %q: %w", pool, err)
+	}
+
+	out := NodePool{
+		Cluster: c.Name,
+		Name:    np.Name,
+		Status:  np.Status,
+	}
+
+	if np.Autoscaling != nil && np.Autoscaling.Enabled {
+		out.Autoscaling = true
+		out.MinNodes = int(np.Autoscaling.MinNodeCount)
+		out.MaxNodes = int(np.Autoscaling.MaxNodeCount)
+	}
+
+	if len(np.InstanceGroupUrls) > 0 {
+		project, zone, name := parseInstanceGroupURL(np.InstanceGroupUrls[0])
+		mig, err := gce.NewInstanceGroups(project, zone).Get(ctx, name)
+		if err != nil {
+			return NodePool{}, fmt.Errorf("node pool %q size: %w", pool, err)
+		}
+		out.NodeCount = mig.TargetSize
+	}
+
+	return out, nil
+}
+
+// parseInstanceGroupURL returns the project, zone and name of an instance group from its URL.
+func parseInstanceGroupURL(url string) (string, string, string) {
+	var project, zone string
+
+	parts := strings.Split(url, "/")
+	for i := 0; i < len(parts)-1; i++ {
+		switch parts[i] {
+		case "projects":
+			project = parts[i+1]
+		case "zones":
+			zone = parts[i+1]
+		}
+	}
+
+	return project, zone, parts[len(parts)-1]
+}
+
+// SetSize will set the number of nodes per zone of a node pool.
+func (c *Cluster) SetSize(ctx context.Context, pool string, count int) error {
+	svc, err := container.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("new container service: %w", err)
+	}
+
+	req := &container.SetNodePoolSizeRequest{
+		NodeCount: int64(count),
+	}
+
+	op, err := svc.Projects.Locations.Clusters.NodePools.SetSize(c.nodePoolName(pool), req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("set size node pool %q: %w", pool, err)
+	}
+
+	return c.wait(ctx, svc, op.Name)
+}
+
+// SetAutoscaling will enable autoscaling of a node pool with provided bounds, or disable it.
+func (c *Cluster) SetAutoscaling(ctx context.Context, pool string, enabled bool, minNodes int, maxNodes int) error {
+	svc, err := container.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("new container service: %w", err)
+	}
+
+	req := &container.SetNodePoolAutoscalingRequest{
+		Autoscaling: &container.NodePoolAutoscaling{
+			Enabled:         enabled,
+			MinNodeCount:    int64(minNodes),
+			MaxNodeCount:    int64(maxNodes),
+			ForceSendFields: []string{"Enabled"},
+		},
+	}
+
+	op, err := svc.Projects.Locations.Clusters.NodePools.SetAutoscaling(c.nodePoolName(pool), req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("set autoscaling node pool %q: %w", pool, err)
+	}
+
+	return c.wait(ctx, svc, op.Name)
+}
+
+// wait waits until the operation is done, or the context is done.
+func (c *Cluster) wait(ctx context.Context, svc *container.Service, id string) error {
+	name := fmt.Sprintf("projects/%s/locations/%s/operations/%s", c.Project, c.Location, id)
+
+	ticker := time.NewTicker(20 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		op, err := svc.Projects.Locations.Operations.Get(name).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("wait operation: %w", err)
+		}
+
+		if op.Status == "DONE" {
+			if op.Error != nil && op.Error.Message != "" {
+				return fmt.Errorf("operation %q: %s", id, op.Error.Message)
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait operation %q: %w", id, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
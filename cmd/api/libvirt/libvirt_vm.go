@@ -0,0 +1,276 @@
+// Package libvirt implements the compute provider for local libvirt/QEMU domains.
+package libvirt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/marintailor/rcstate/cmd/api/provider"
+)
+
+// DefaultURI is the libvirt connection URI used when no URI is provided.
+const DefaultURI = "qemu:///system"
+
+// Domains stores list of domains managed by a libvirt connection.
+// It implements the provider.Provider and provider.Suspender interfaces for libvirt.
+type Domains struct {
+	List []provider.Instance
+	URI  string
+}
+
+// NewDomains returns a Domains struct with provided connection URI.
+func NewDomains(uri string) *Domains {
+	if uri == "" {
+		uri = DefaultURI
+	}
+
+	return &Domains{
+		URI: uri,
+	}
+}
+
+// virsh runs a virsh command against the connection URI and returns its output.
+func (d *Domains) virsh(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "virsh", append([]string{"--connect", d.URI}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("virsh %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// GetList returns a slice of Instance.
+func (d *Domains) GetList(ctx context.Context) ([]provider.Instance, error) {
+	out, err := d.virsh(ctx, "list", "--all", "--name")
+	if err != nil {
+		return []provider.Instance{}, fmt.Errorf("list domains: %w", err)
+	}
+
+	d.List = nil
+
+	// Domain names may contain spaces, so the output has one name per line.
+	for _, name := range strings.Split(out, "\n") {
+		if name = strings.TrimRight(name, "\r"); name == "" {
+			continue
+		}
+
+		status, err := d.Status(ctx, name)
+		if err != nil {
+			return []provider.Instance{}, err
+		}
+
+		// The list does not wait for the lease of a domain just started.
+		var ip string
+		if status == "RUNNING" {
+			ip, err = d.leaseIP(ctx, name)
+			if err != nil {
+				return []provider.Instance{}, err
+			}
+		}
+
+		d.List = append(d.List, provider.Instance{
+			Name:     name,
+			Status:   status,
+			Internal: ip,
+			External: ip,
+			Type:     d.domainType(ctx, name),
+		})
+	}
+
+	return d.List, nil
+}
+
+// domainType returns the number of virtual CPUs and memory of the domain.
+func (d *Domains) domainType(ctx context.Context, name string) string {
+	out, err := d.virsh(ctx, "dominfo", name)
+	if err != nil {
+		return ""
+	}
+
+	var cpu, mem string
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		k, v, ok := strings.Cut(s.Text(), ":")
+		if !ok {
+			continue
+		}
+
+		switch strings.TrimSpace(k) {
+		case "CPU(s)":
+			cpu = strings.TrimSpace(v)
+		case "Max memory":
+			mem = strings.TrimSpace(v)
+		}
+	}
+
+	return fmt.Sprintf("%s vCPU, %s", cpu, mem)
+}
+
+// Start will start a domain. A running domain is left as it is.
+func (d *Domains) Start(ctx context.Context, name string) error {
+	status, err := d.Status(ctx, name)
+	if err != nil || status == "RUNNING" {
+		return err
+	}
+
+	if _, err := d.virsh(ctx, "start", name); err != nil {
+		return fmt.Errorf("start domain: %w", err)
+	}
+
+	return d.wait(ctx, name, "RUNNING")
+}
+
+// Suspend pauses the domain, keeping its memory state.
+func (d *Domains) Suspend(ctx context.Context, name string) error {
+	if _, err := d.virsh(ctx, "suspend", name); err != nil {
+		return fmt.Errorf("suspend domain: %w", err)
+	}
+
+	return d.wait(ctx, name, "SUSPENDED")
+}
+
+// Resume resumes a paused domain, or wakes up a domain suspended by the guest power management.
+func (d *Domains) Resume(ctx context.Context, name string) error {
+	state, err := d.domstate(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	command := "resume"
+	if state == "pmsuspended" {
+		command = "dompmwakeup"
+	}
+
+	if _, err := d.virsh(ctx, command, name); err != nil {
+		return fmt.Errorf("resume domain: %w", err)
+	}
+
+	return d.wait(ctx, name, "RUNNING")
+}
+
+// domstate returns the libvirt state of the domain.
+func (d *Domains) domstate(ctx context.Context, name string) (string, error) {
+	out, err := d.virsh(ctx, "domstate", name)
+	if err != nil {
+		return "", fmt.Errorf("get status domain %q: %w", name, err)
+	}
+
+	return out, nil
+}
+
+// Status returns the status of the domain.
+// Domain states are mapped to the equivalent Compute Engine instance statuses.
+func (d *Domains) Status(ctx context.Context, name string) (string, error) {
+	out, err := d.domstate(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	switch out {
+	case "running":
+		return "RUNNING", nil
+	case "shut off":
+		return "TERMINATED", nil
+	case "paused", "pmsuspended":
+		return "SUSPENDED", nil
+	default:
+		return strings.ToUpper(strings.ReplaceAll(out, " ", "_")), nil
+	}
+}
+
+// Stop will gracefully shutdown the domain. A shut off domain is left as it is.
+func (d *Domains) Stop(ctx context.Context, name string) error {
+	status, err := d.Status(ctx, name)
+	if err != nil || status == "TERMINATED" {
+		return err
+	}
+
+	if _, err := d.virsh(ctx, "shutdown", name); err != nil {
+		return fmt.Errorf("shutdown domain: %w", err)
+	}
+
+	return d.wait(ctx, name, "TERMINATED")
+}
+
+// wait waits until the domain reaches the status, or the context is done.
+func (d *Domains) wait(ctx context.Context, name string, status string) error {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		s, err := d.Status(ctx, name)
+		if err != nil {
+			return fmt.Errorf("wait operation: %w", err)
+		}
+
+		if s == status {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait domain %q status %q: %w", name, status, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// leaseTimeout bounds the wait for the DHCP lease of a domain.
+const leaseTimeout = 2 * time.Minute
+
+// ExternalIP returns the IP address of the domain from the DHCP leases.
+// A domain just started may not have a lease yet, so it waits until the lease is found, or the wait times out.
+func (d *Domains) ExternalIP(ctx context.Context, name string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, leaseTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		ip, err := d.leaseIP(ctx, name)
+		if err != nil || ip != "" {
+			return ip, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("domain %q has no DHCP lease: %w", name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// leaseIP returns the IP address of the domain from the DHCP leases, or an empty string if there is no lease.
+func (d *Domains) leaseIP(ctx context.Context, name string) (string, error) {
+	out, err := d.virsh(ctx, "domifaddr", name, "--source", "lease")
+	if err != nil {
+		return "", fmt.Errorf("domain address: %w", err)
+	}
+
+	return getLeaseIP(out), nil
+}
+
+// getLeaseIP returns the first IPv4 address from the output of virsh domifaddr.
+func getLeaseIP(out string) string {
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 4 && fields[2] == "ipv4" {
+			ip, _, _ := strings.Cut(fields[3], "/")
+			return ip
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,109 @@
+// Package provider defines the interface implemented by compute backends.
+package provider
+
+import "context"
+
+// Names of supported providers.
+const (
+	AWS     = "aws"
+	GCE     = "gce"
+	Libvirt = "libvirt"
+)
+
+// Instance stores details of an instance.
+// ID is set by providers that identify instances by an ID other than the name.
+type Instance struct {
+	ID          string            `json:"id,omitempty"`
+	Name        string            `json:"name"`
+	Status      string            `json:"status"`
+	Internal    string            `json:"internal"`
+	External    string            `json:"external"`
+	Type        string            `json:"type"`
+	Preemptible bool              `json:"preemptible"`
+	Zone        string            `json:"zone"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// Is returns whether the instance is the instance declared by name or by ID.
+func (i Instance) Is(name string) bool {
+	return name == i.Name || (i.ID != "" && name == i.ID)
+}
+
+// Snapshot stores details of a disk snapshot of an instance.
+type Snapshot struct {
+	Instance string `json:"instance"`
+	Disk     string `json:"disk"`
+	Name     string `json:"name"`
+	Created  string `json:"created"`
+	Status   string `json:"status"`
+}
+
+// Provider manages the state of instances in a compute backend.
+type Provider interface {
+	// ExternalIP returns the external IP address of the instance.
+	ExternalIP(ctx context.Context, name string) (string, error)
+
+	// GetList returns the list of instances.
+	GetList(ctx context.Context) ([]Instance, error)
+
+	// Start starts the instance and waits until the operation is done.
+	Start(ctx context.Context, name string) error
+
+	// Status returns the status of the instance.
+	Status(ctx context.Context, name string) (string, error)
+
+	// Stop stops the instance and waits until the operation is done.
+	Stop(ctx context.Context, name string) error
+}
+
+// Suspender is implemented by providers that can suspend and resume instances.
+type Suspender interface {
+	// Resume resumes the suspended instance and waits until the operation is done.
+	Resume(ctx context.Context, name string) error
+
+	// Suspend suspends the instance and waits until the operation is done.
+	Suspend(ctx context.Context, name string) error
+}
+
+// AggregatedLister is implemented by providers that can list instances from all zones at once.
+type AggregatedLister interface {
+	// GetAggregatedList returns the list of instances from all zones.
+	GetAggregatedList(ctx context.Context) ([]Instance, error)
+}
+
+// MachineTyper is implemented by providers that can change the machine type of stopped instances.
+type MachineTyper interface {
+	// MachineType returns the machine type of the instance.
+	MachineType(ctx context.Context, name string) (string, error)
+
+	// SetMachineType changes the machine type of the stopped instance.
+	SetMachineType(ctx context.Context, name string, machineType string) error
+}
+
+// Snapshotter is implemented by providers that can snapshot the disks of instances.
+type Snapshotter interface {
+	// Snapshot creates a snapshot of each disk attached to the instance,
+	// and deletes older snapshots beyond the retention count per disk.
+	Snapshot(ctx context.Context, name string, retention int) error
+
+	// Snapshots returns the snapshots of the instance's disks, newest first.
+	Snapshots(ctx context.Context, name string) ([]Snapshot, error)
+}
+
+// GuestReader is implemented by providers that can read data published by the guest of an instance.
+type GuestReader interface {
+	// GuestAttribute returns the value of the guest attribute in format "namespace/key".
+	GuestAttribute(ctx context.Context, name string, key string) (string, error)
+
+	// SerialOutput returns the recent output of the first serial port of the instance.
+	SerialOutput(ctx context.Context, name string) (string, error)
+}
+
+// Options stores the details required to select and configure a provider.
+type Options struct {
+	Endpoint string
+	Name     string
+	Project  string
+	Region   string
+	Zone     string
+}
@@ -2,22 +2,23 @@
 package record
 
 import (
+	"context"
 	"fmt"
-	"net"
-	"os"
-	"time"
-
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/route53"
+	"net/netip"
+	"sort"
+	"strings"
 )
 
 // Record is a struct that holds required data and methods to create a DNS record.
 type Record struct {
 	Domain string
 	IP     []string
+	TTL    int64
 	Type   string
 	Zone   string
+
+	// proxied stores the proxied flag of each value of a record set looked up in Cloudflare.
+	proxied []bool
 }
 
 // NewRecord returns a Record struct.
@@ -30,144 +31,200 @@ func NewRecord(ip []string, rt string, zone string, domain string) *Record {
 	}
 }
 
-// Route53 creates a DNS record in Route 53 DNS service.
-func (r *Record) Route53() error {
-	if err := awsCredentials(); err != nil {
-		return fmt.Errorf("aws credentials: %w", err)
-	}
+// defaultTTL is the TTL in seconds of the records created without a TTL.
+const defaultTTL int64 = 10
 
-	sess, err := session.NewSession()
-	if err != nil {
-		return fmt.Errorf("new session: %w", err)
-	}
+// Names of supported DNS providers.
+const (
+	CloudDNS   = "clouddns"
+	Cloudflare = "cloudflare"
+	RFC2136    = "rfc2136"
+	Route53    = "route53"
+	Webhook    = "webhook"
+)
 
-	svc := route53.New(sess)
+// Provider manages DNS records in a DNS service.
+type Provider interface {
+	// Delete deletes the record, and waits until the change is applied.
+	Delete(ctx context.Context, r *Record) error
 
-	hostedZoneNameInput := &route53.ListHostedZonesByNameInput{
-		DNSName: &r.Domain,
-	}
+	// Lookup returns the record with the same name and type, or nil if there is none.
+	Lookup(ctx context.Context, r *Record) (*Record, error)
 
-	list, err := svc.ListHostedZonesByName(hostedZoneNameInput)
-	if err != nil {
-		return fmt.Errorf("hosted zone by name: %w", err)
-	}
+	// Upsert creates or replaces the record, and waits until the change is applied.
+	Upsert(ctx context.Context, r *Record) error
+}
 
-	hostedZoneID := getHostedZoneID(r.Domain+".", list.HostedZones)
+// Options stores the options to select and configure a DNS provider.
+type Options struct {
+	Name    string
+	Project string
 
-	hostedZoneInput := &route53.GetHostedZoneInput{
-		Id: hostedZoneID,
-	}
+	// Endpoint is the API endpoint of providers cloudflare and webhook.
+	Endpoint string
 
-	hostedZone, err := svc.GetHostedZone(hostedZoneInput)
-	if err != nil {
-		return fmt.Errorf("hosted zone: %w", err)
-	}
+	// Proxied proxies the traffic of the records of provider cloudflare.
+	Proxied bool
 
-	zoneID := hostedZone.HostedZone.Id
-	if err != nil {
-		return fmt.Errorf("zone id: %w", err)
-	}
+	// Server is the address of the DNS server of provider rfc2136.
+	Server string
 
-	var ttl int64 = 10
+	// TokenFile is the file with the API token of providers cloudflare and webhook.
+	TokenFile string
 
-	var ipList []*route53.ResourceRecord
+	// TSIG is the key of provider rfc2136.
+	TSIG TSIG
+}
 
-	for _, v := range r.IP {
-		ipList = append(ipList, &route53.ResourceRecord{Value: aws.String(v)})
+// NewProvider returns the DNS provider selected by the options. Route 53 is used if no provider is selected.
+func NewProvider(o Options) (Provider, error) {
+	switch o.Name {
+	case "", Route53:
+		return NewRoute53Records(), nil
+	case CloudDNS:
+		if o.Project == "" {
+			return nil, fmt.Errorf("dns provider %q requires a project", o.Name)
+		}
+		return NewCloudDNSRecords(o.Project), nil
+	case RFC2136:
+		if o.Server == "" {
+			return nil, fmt.Errorf("dns provider %q requires a server", o.Name)
+		}
+		if err := o.TSIG.loadSecret(); err != nil {
+			return nil, err
+		}
+		if _, _, err := o.TSIG.algorithm(); err != nil {
+			return nil, err
+		}
+		return NewRFC2136Records(o.Server, o.TSIG), nil
+	case Cloudflare:
+		token, err := loadToken(o.TokenFile, cloudflareTokenEnv)
+		if err != nil {
+			return nil, err
+		}
+		if token == "" {
+			return nil, fmt.Errorf("dns provider %q requires a token file or %s", o.Name, cloudflareTokenEnv)
+		}
+		return NewCloudflareRecords(o.Endpoint, token, o.Proxied), nil
+	case Webhook:
+		if o.Endpoint == "" {
+			return nil, fmt.Errorf("dns provider %q requires an endpoint", o.Name)
+		}
+		token, err := loadToken(o.TokenFile, webhookTokenEnv)
+		if err != nil {
+			return nil, err
+		}
+		return NewWebhookRecords(o.Endpoint, token), nil
+	default:
+		return nil, fmt.Errorf("unknown dns provider %q", o.Name)
 	}
+}
 
-	params := &route53.ChangeResourceRecordSetsInput{
-		ChangeBatch: &route53.ChangeBatch{
-			Changes: []*route53.Change{
-				{
-					Action: aws.String("UPSERT"),
-					ResourceRecordSet: &route53.ResourceRecordSet{
-						Name:            aws.String(r.Zone),
-						Type:            aws.String(r.Type),
-						ResourceRecords: ipList,
-						TTL:             aws.Int64(ttl),
-					},
-				},
-			},
-			Comment: aws.String("Update record to reflect new IP address for a system"),
-		},
-		HostedZoneId: aws.String(*zoneID),
+// ttl returns the TTL of the record, or the default TTL if it is not set.
+func (r *Record) ttl() int64 {
+	if r.TTL == 0 {
+		return defaultTTL
 	}
 
-	resp, err := svc.ChangeResourceRecordSets(params)
-	if err != nil {
-		return fmt.Errorf("resp: %w", err)
-	}
+	return r.TTL
+}
 
-	changeID := &route53.GetChangeInput{
-		Id: resp.ChangeInfo.Id,
-	}
+// recordTTL is implemented by DNS providers that store another TTL than the TTL of the record.
+type recordTTL interface {
+	ttl(r *Record) int64
+}
 
-	return checkChange(svc, changeID)
+// recordSettings is implemented by DNS providers that store settings of a record other than its values and TTL.
+type recordSettings interface {
+	// settingsDiff describes how the settings of the current record set differ from the settings of the provider.
+	settingsDiff(current *Record) []string
 }
 
-// awsCredentials ensures that AWS credentials are present.
-func awsCredentials() error {
-	home, _ := os.UserHomeDir()
-	path := home + "/.aws/credentials"
-	if _, err := os.Stat(path); err != nil {
-		return fmt.Errorf("env file stat: %w", err)
-	}
+// Status stores the comparison of a record with the current record set of its DNS provider.
+type Status struct {
+	// Current is the current record set, or nil if there is none.
+	Current *Record
 
-	return nil
+	// Diff describes how the current record set differs from the record, and is empty if it is up to date.
+	Diff []string
 }
 
-// getHostedZoneID returns pointer string of the Hosted Zone ID
-func getHostedZoneID(d string, l []*route53.HostedZone) *string {
-	for _, hz := range l {
-		if *hz.Name == d {
-			return hz.Id
-		}
-	}
-	return nil
+// UpToDate returns whether the current record set matches the record.
+func (s *Status) UpToDate() bool {
+	return len(s.Diff) == 0
 }
 
-// checkChange checks status of change in record.
-func checkChange(svc *route53.Route53, id *route53.GetChangeInput) error {
-	for i := 0; i < 31; i++ {
-		if i == 30 {
-			return fmt.Errorf("check change status: %s", "1 minute timeout")
-		}
+// Check compares the record with the current record set of the DNS provider, by values, TTL, type,
+// and the settings of providers that store other settings.
+// A record without values is up to date if there is no record set.
+// A CNAME record with the same name is reported for a record of another type, as it takes the place of the record.
+func Check(ctx context.Context, p Provider, r *Record) (*Status, error) {
+	current, err := p.Lookup(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("lookup record: %w", err)
+	}
 
-		change, err := svc.GetChange(id)
+	s := &Status{Current: current}
+
+	if current == nil && !strings.EqualFold(r.Type, "CNAME") {
+		cname, err := p.Lookup(ctx, NewRecord(nil, "CNAME", r.Zone, r.Domain))
 		if err != nil {
-			return fmt.Errorf("change status: %w", err)
+			return nil, fmt.Errorf("lookup CNAME record: %w", err)
 		}
 
-		if *change.ChangeInfo.Status == "INSYNC" {
-			return nil
+		if cname != nil {
+			s.Diff = append(s.Diff, fmt.Sprintf("type is CNAME %s, want %s", strings.Join(cname.IP, ", "), r.Type))
+			return s, nil
 		}
+	}
 
-		time.Sleep(2 * time.Second)
+	switch {
+	case len(r.IP) == 0 && current == nil:
+		return s, nil
+	case len(r.IP) == 0:
+		s.Diff = append(s.Diff, fmt.Sprintf("record exists with %s, want none", strings.Join(current.IP, ", ")))
+		return s, nil
+	case current == nil:
+		s.Diff = append(s.Diff, "record does not exist")
+		return s, nil
 	}
 
-	return nil
-}
+	have, want := normalizeValues(current.IP), normalizeValues(r.IP)
+	if strings.Join(have, ",") != strings.Join(want, ",") {
+		s.Diff = append(s.Diff, fmt.Sprintf("values are %s, want %s", strings.Join(have, ", "), strings.Join(want, ", ")))
+	}
 
-// CheckRecordIP will check is the record IP addresses are up-to-date.
-func CheckRecordIP(zone string, recordIP []string) bool {
-	IPs, err := net.LookupIP(zone)
-	if err != nil {
-		fmt.Println("lookup ip:", err)
+	ttl := r.ttl()
+	if t, ok := p.(recordTTL); ok {
+		ttl = t.ttl(r)
+	}
+
+	if current.TTL != ttl {
+		s.Diff = append(s.Diff, fmt.Sprintf("ttl is %d, want %d", current.TTL, ttl))
 	}
 
-	if len(IPs) != len(recordIP) {
-		return false
+	if rs, ok := p.(recordSettings); ok {
+		s.Diff = append(s.Diff, rs.settingsDiff(current)...)
 	}
 
-	count := 0
-	for _, ip := range IPs {
-		for _, rec := range recordIP {
-			if ip.String() == rec {
-				count++
-			}
+	return s, nil
+}
+
+// normalizeValues returns the sorted values of a record in canonical form,
+// IP addresses in their shortest form, and names in lower case without the trailing dot.
+func normalizeValues(values []string) []string {
+	var normalized []string
+
+	for _, v := range values {
+		if ip, err := netip.ParseAddr(v); err == nil {
+			normalized = append(normalized, ip.String())
+			continue
 		}
+
+		normalized = append(normalized, strings.ToLower(strings.TrimSuffix(v, ".")))
 	}
 
-	return count == len(IPs)
+	sort.Strings(normalized)
+
+	return normalized
 }
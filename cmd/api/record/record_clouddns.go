@@ -0,0 +1,182 @@
+package record
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	dns "google.golang.org/api/dns/v1"
+)
+
+// CloudDNSRecords manages DNS records in the Cloud DNS managed zones of a project.
+// It implements the Provider interface.
+type CloudDNSRecords struct {
+	Project string
+}
+
+// NewCloudDNSRecords returns a CloudDNSRecords struct with provided project.
+func NewCloudDNSRecords(project string) *CloudDNSRecords {
+	return &CloudDNSRecords{
+		Project: project,
+	}
+}
+
+// Upsert creates or replaces the DNS record, and waits until the change is done.
+func (c *CloudDNSRecords) Upsert(ctx context.Context, r *Record) error {
+	svc, zone, err := c.zone(ctx, r.Domain)
+	if err != nil {
+		return err
+	}
+
+	existing, err := c.recordSet(ctx, svc, zone, r)
+	if err != nil {
+		return err
+	}
+
+	rrdatas := r.IP
+	if r.Type == "CNAME" {
+		rrdatas = nil
+		for _, target := range r.IP {
+			rrdatas = append(rrdatas, fqdn(target))
+		}
+	}
+
+	change := &dns.Change{
+		Additions: []*dns.ResourceRecordSet{
+			{
+				Name:    fqdn(r.Zone),
+				Rrdatas: rrdatas,
+				Ttl:     r.ttl(),
+				Type:    r.Type,
+			},
+		},
+	}
+
+	if existing != nil {
+		change.Deletions = []*dns.ResourceRecordSet{existing}
+	}
+
+	return c.change(ctx, svc, zone, change)
+}
+
+// Delete deletes the DNS record, and waits until the change is done.
+func (c *CloudDNSRecords) Delete(ctx context.Context, r *Record) error {
+	svc, zone, err := c.zone(ctx, r.Domain)
+	if err != nil {
+		return err
+	}
+
+	existing, err := c.recordSet(ctx, svc, zone, r)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		return nil
+	}
+
+	return c.change(ctx, svc, zone, &dns.Change{Deletions: []*dns.ResourceRecordSet{existing}})
+}
+
+// Lookup returns the existing DNS record with the same name and type, or nil if there is none.
+func (c *CloudDNSRecords) Lookup(ctx context.Context, r *Record) (*Record, error) {
+	svc, zone, err := c.zone(ctx, r.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := c.recordSet(ctx, svc, zone, r)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	return &Record{
+		Domain: r.Domain,
+		IP:     existing.Rrdatas,
+		TTL:    existing.Ttl,
+		Type:   r.Type,
+		Zone:   r.Zone,
+	}, nil
+}
+
+// zone returns a Cloud DNS service, and the name of the managed zone of the domain.
+// A public zone is preferred over a private zone with the same DNS name.
+func (c *CloudDNSRecords) zone(ctx context.Context, domain string) (*dns.Service, string, error) {
+	svc, err := dns.NewService(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("new dns service: %w", err)
+	}
+
+	list, err := svc.ManagedZones.List(c.Project).DnsName(fqdn(domain)).Context(ctx).Do()
+	if err != nil {
+		return nil, "", fmt.Errorf("list managed zones: %w", err)
+	}
+
+	var name string
+
+	for _, z := range list.ManagedZones {
+		if z.DnsName != fqdn(domain) {
+			continue
+		}
+
+		if z.Visibility != "private" {
+			return svc, z.Name, nil
+		}
+
+		if name == "" {
+			name = z.Name
+		}
+	}
+
+	if name != "" {
+		return svc, name, nil
+	}
+
+	return nil, "", fmt.Errorf("managed zone of domain %q not found in project %q", domain, c.Project)
+}
+
+// recordSet returns the record set with the same name and type as the record, or nil if there is none.
+func (c *CloudDNSRecords) recordSet(ctx context.Context, svc *dns.Service, zone string, r *Record) (*dns.ResourceRecordSet, error) {
+	list, err := svc.ResourceRecordSets.List(c.Project, zone).Name(fqdn(r.Zone)).Type(r.Type).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("list record sets: %w", err)
+	}
+
+	if len(list.Rrsets) == 0 {
+		return nil, nil
+	}
+
+	return list.Rrsets[0], nil
+}
+
+// change applies the change in the managed zone, and waits until it is done, or the context is done.
+func (c *CloudDNSRecords) change(ctx context.Context, svc *dns.Service, zone string, change *dns.Change) error {
+	resp, err := svc.Changes.Create(c.Project, zone, change).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("create change: %w", err)
+	}
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for resp.Status != "done" {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("check change status: %w", ctx.Err())
+		case <-ticker.C:
+		}
+
+		resp, err = svc.Changes.Get(c.Project, zone, resp.Id).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("change status: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// fqdn returns the fully qualified name with the trailing dot.
+func fqdn(name string) string {
+	return strings.TrimSuffix(name, ".") + "."
+}
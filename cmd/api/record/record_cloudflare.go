@@ -0,0 +1,247 @@
+package record
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// cloudflareEndpoint is the default endpoint of the Cloudflare API.
+const cloudflareEndpoint = "https://api.cloudflare.com/client/v4"
+
+// cloudflareTokenEnv is the environment variable read for the Cloudflare API token if no token file is provided.
+const cloudflareTokenEnv = "CLOUDFLARE_API_TOKEN"
+
+// cloudflareAutoTTL is the TTL value of Cloudflare for an automatic TTL, required by proxied records.
+const cloudflareAutoTTL int64 = 1
+
+// CloudflareRecords manages DNS records in the Cloudflare zones of an account.
+// It implements the Provider interface.
+type CloudflareRecords struct {
+	Endpoint string
+	Proxied  bool
+	Token    string
+}
+
+// cloudflareResponse is the envelope of the Cloudflare API responses.
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result interface{} `json:"result"`
+}
+
+// cloudflareRecord is a DNS record of the Cloudflare API.
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Content string `json:"content"`
+	Name    string `json:"name"`
+	Proxied bool   `json:"proxied"`
+	TTL     int64  `json:"ttl"`
+	Type    string `json:"type"`
+}
+
+// NewCloudflareRecords returns a CloudflareRecords struct with provided API endpoint and token.
+// The Cloudflare API endpoint is used if no endpoint is provided.
+func NewCloudflareRecords(endpoint string, token string, proxied bool) *CloudflareRecords {
+	if endpoint == "" {
+		endpoint = cloudflareEndpoint
+	}
+
+	return &CloudflareRecords{
+		Endpoint: strings.TrimSuffix(endpoint, "/"),
+		Proxied:  proxied,
+		Token:    token,
+	}
+}
+
+// Upsert replaces the records with the same name and type with the DNS record.
+// Records with a value of the DNS record are updated, the others are deleted.
+func (c *CloudflareRecords) Upsert(ctx context.Context, r *Record) error {
+	zone, err := c.zone(ctx, r.Domain)
+	if err != nil {
+		return err
+	}
+
+	existing, err := c.records(ctx, zone, r)
+	if err != nil {
+		return err
+	}
+
+	byContent := map[string]cloudflareRecord{}
+	for _, e := range existing {
+		byContent[e.Content] = e
+	}
+
+	for _, ip := range r.IP {
+		rec := cloudflareRecord{
+			Content: ip,
+			Name:    strings.TrimSuffix(r.Zone, "."),
+			Proxied: c.Proxied,
+			TTL:     c.ttl(r),
+			Type:    r.Type,
+		}
+
+		e, ok := byContent[ip]
+		delete(byContent, ip)
+
+		if !ok {
+			if err := c.do(ctx, http.MethodPost, "/zones/"+zone+"/dns_records", rec, nil); err != nil {
+				return fmt.Errorf("create record %s: %w", ip, err)
+			}
+			continue
+		}
+
+		if e.TTL == rec.TTL && e.Proxied == rec.Proxied {
+			continue
+		}
+
+		if err := c.do(ctx, http.MethodPut, "/zones/"+zone+"/dns_records/"+e.ID, rec, nil); err != nil {
+			return fmt.Errorf("update record %s: %w", ip, err)
+		}
+	}
+
+	for _, e := range byContent {
+		if err := c.do(ctx, http.MethodDelete, "/zones/"+zone+"/dns_records/"+e.ID, nil, nil); err != nil {
+			return fmt.Errorf("delete record %s: %w", e.Content, err)
+		}
+	}
+
+	return nil
+}
+
+// Delete deletes the records with the same name and type as the DNS record.
+func (c *CloudflareRecords) Delete(ctx context.Context, r *Record) error {
+	zone, err := c.zone(ctx, r.Domain)
+	if err != nil {
+		return err
+	}
+
+	existing, err := c.records(ctx, zone, r)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range existing {
+		if err := c.do(ctx, http.MethodDelete, "/zones/"+zone+"/dns_records/"+e.ID, nil, nil); err != nil {
+			return fmt.Errorf("delete record %s: %w", e.Content, err)
+		}
+	}
+
+	return nil
+}
+
+// Lookup returns the existing records with the same name and type as one DNS record, or nil if there is none.
+func (c *CloudflareRecords) Lookup(ctx context.Context, r *Record) (*Record, error) {
+	zone, err := c.zone(ctx, r.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := c.records(ctx, zone, r)
+	if err != nil || len(existing) == 0 {
+		return nil, err
+	}
+
+	found := &Record{
+		Domain: r.Domain,
+		TTL:    existing[0].TTL,
+		Type:   r.Type,
+		Zone:   r.Zone,
+	}
+
+	for _, e := range existing {
+		found.IP = append(found.IP, e.Content)
+		found.proxied = append(found.proxied, e.Proxied)
+	}
+
+	return found, nil
+}
+
+// settingsDiff describes the records of the current record set with another proxied flag than the provider.
+func (c *CloudflareRecords) settingsDiff(current *Record) []string {
+	var diff []string
+
+	for i, proxied := range current.proxied {
+		if proxied != c.Proxied {
+			diff = append(diff, fmt.Sprintf("proxied is %t for %s, want %t", proxied, current.IP[i], c.Proxied))
+		}
+	}
+
+	return diff
+}
+
+// ttl returns the TTL of the record in Cloudflare. Proxied records and records without a TTL use the automatic TTL.
+func (c *CloudflareRecords) ttl(r *Record) int64 {
+	if c.Proxied || r.TTL == 0 {
+		return cloudflareAutoTTL
+	}
+
+	return r.TTL
+}
+
+// zone returns the ID of the zone of the domain.
+func (c *CloudflareRecords) zone(ctx context.Context, domain string) (string, error) {
+	var zones []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	name := strings.TrimSuffix(domain, ".")
+
+	if err := c.do(ctx, http.MethodGet, "/zones?name="+url.QueryEscape(name), nil, &zones); err != nil {
+		return "", fmt.Errorf("list zones: %w", err)
+	}
+
+	for _, z := range zones {
+		if strings.EqualFold(z.Name, name) {
+			return z.ID, nil
+		}
+	}
+
+	return "", fmt.Errorf("cloudflare zone of domain %q not found", domain)
+}
+
+// records returns the records of the zone with the same name and type as the record.
+func (c *CloudflareRecords) records(ctx context.Context, zone string, r *Record) ([]cloudflareRecord, error) {
+	var records []cloudflareRecord
+
+	query := url.Values{}
+	query.Set("name", strings.TrimSuffix(r.Zone, "."))
+	query.Set("type", r.Type)
+
+	if err := c.do(ctx, http.MethodGet, "/zones/"+zone+"/dns_records?"+query.Encode(), nil, &records); err != nil {
+		return nil, fmt.Errorf("list records: %w", err)
+	}
+
+	return records, nil
+}
+
+// do sends the request to the Cloudflare API, and decodes the result of the response into result if provided.
+func (c *CloudflareRecords) do(ctx context.Context, method string, path string, body interface{}, result interface{}) error {
+	resp := cloudflareResponse{Result: result}
+
+	status, err := doJSON(ctx, method, c.Endpoint+path, c.Token, body, &resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.Success && status < 300 {
+		return nil
+	}
+
+	var messages []string
+	for _, e := range resp.Errors {
+		messages = append(messages, fmt.Sprintf("%d %s", e.Code, e.Message))
+	}
+
+	if len(messages) == 0 {
+		messages = append(messages, http.StatusText(status))
+	}
+
+	return fmt.Errorf("cloudflare api status %d: %s", status, strings.Join(messages, ", "))
+}
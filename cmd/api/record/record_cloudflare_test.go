@@ -0,0 +1,237 @@
+package record
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// cloudflareStub is a local stand-in of the Cloudflare API, with a single zone example.com.
+type cloudflareStub struct {
+	mu      sync.Mutex
+	nextID  int
+	records map[string]cloudflareRecord
+	methods []string
+}
+
+func newCloudflareStub(t *testing.T) (*cloudflareStub, *CloudflareRecords) {
+	t.Helper()
+
+	s := &cloudflareStub{records: map[string]cloudflareRecord{}}
+
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	return s, NewCloudflareRecords(srv.URL+"/client/v4/", "token", false)
+}
+
+func (s *cloudflareStub) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.Header.Get("Authorization") != "Bearer token" {
+		s.reply(w, http.StatusForbidden, nil, "Authentication error")
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/client/v4")
+	query := req.URL.Query()
+
+	switch {
+	case req.Method == http.MethodGet && path == "/zones":
+		var zones []map[string]string
+		if strings.EqualFold(query.Get("name"), "example.com") {
+			zones = append(zones, map[string]string{"id": "zone", "name": "example.com"})
+		}
+		s.reply(w, http.StatusOK, zones, "")
+	case req.Method == http.MethodGet && path == "/zones/zone/dns_records":
+		var found []cloudflareRecord
+		for _, r := range s.records {
+			if r.Name == query.Get("name") && r.Type == query.Get("type") {
+				found = append(found, r)
+			}
+		}
+		sort.Slice(found, func(i, j int) bool { return found[i].Content < found[j].Content })
+		s.reply(w, http.StatusOK, found, "")
+	case req.Method == http.MethodPost && path == "/zones/zone/dns_records":
+		var r cloudflareRecord
+		if err := json.NewDecoder(req.Body).Decode(&r); err != nil {
+			s.reply(w, http.StatusBadRequest, nil, err.Error())
+			return
+		}
+		s.nextID++
+		r.ID = fmt.Sprintf("record-%d", s.nextID)
+		s.records[r.ID] = r
+		s.methods = append(s.methods, "POST "+r.Content)
+		s.reply(w, http.StatusOK, r, "")
+	case strings.HasPrefix(path, "/zones/zone/dns_records/"):
+		id := strings.TrimPrefix(path, "/zones/zone/dns_records/")
+		current, ok := s.records[id]
+		if !ok {
+			s.reply(w, http.StatusNotFound, nil, "Record not found")
+			return
+		}
+
+		switch req.Method {
+		case http.MethodPut:
+			var r cloudflareRecord
+			if err := json.NewDecoder(req.Body).Decode(&r); err != nil {
+				s.reply(w, http.StatusBadRequest, nil, err.Error())
+				return
+			}
+			r.ID = id
+			s.records[id] = r
+			s.methods = append(s.methods, "PUT "+r.Content)
+			s.reply(w, http.StatusOK, r, "")
+		case http.MethodDelete:
+			delete(s.records, id)
+			s.methods = append(s.methods, "DELETE "+current.Content)
+			s.reply(w, http.StatusOK, map[string]string{"id": id}, "")
+		default:
+			s.reply(w, http.StatusMethodNotAllowed, nil, "Method not allowed")
+		}
+	default:
+		s.reply(w, http.StatusNotFound, nil, "Not found")
+	}
+}
+
+// reply writes a response in the envelope of the Cloudflare API.
+func (s *cloudflareStub) reply(w http.ResponseWriter, status int, result interface{}, message string) {
+	resp := map[string]interface{}{"success": message == "", "result": result, "errors": []interface{}{}}
+	if message != "" {
+		resp["errors"] = []map[string]interface{}{{"code": status, "message": message}}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// calls returns and resets the changes received by the stand-in.
+func (s *cloudflareStub) calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calls := s.methods
+	s.methods = nil
+
+	return calls
+}
+
+func TestCloudflareRecords(t *testing.T) {
+	s, c := newCloudflareStub(t)
+	ctx := context.Background()
+
+	check := func(r *Record) []string {
+		t.Helper()
+
+		status, err := Check(ctx, c, r)
+		if err != nil {
+			t.Fatalf("check: %s", err)
+		}
+
+		return status.Diff
+	}
+
+	rec := NewRecord([]string{"10.0.0.1", "10.0.0.2"}, "A", "www.example.com", "example.com")
+
+	if diff := check(rec); len(diff) != 1 || diff[0] != "record does not exist" {
+		t.Errorf("diff before upsert = %v", diff)
+	}
+
+	if err := c.Upsert(ctx, rec); err != nil {
+		t.Fatalf("upsert: %s", err)
+	}
+
+	if got, want := s.calls(), []string{"POST 10.0.0.1", "POST 10.0.0.2"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("upsert calls = %v, want %v", got, want)
+	}
+
+	if diff := check(rec); len(diff) != 0 {
+		t.Errorf("diff after upsert = %v", diff)
+	}
+
+	// An unchanged record is kept, and a record of another value is deleted.
+	rec = NewRecord([]string{"10.0.0.2", "10.0.0.3"}, "A", "www.example.com", "example.com")
+	if err := c.Upsert(ctx, rec); err != nil {
+		t.Fatalf("upsert: %s", err)
+	}
+
+	if got, want := s.calls(), []string{"POST 10.0.0.3", "DELETE 10.0.0.1"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("replace calls = %v, want %v", got, want)
+	}
+
+	if diff := check(rec); len(diff) != 0 {
+		t.Errorf("diff after replace = %v", diff)
+	}
+
+	// A record with another proxied flag is out of sync, and updated by the next upsert.
+	s.mu.Lock()
+	for id, r := range s.records {
+		if r.Content == "10.0.0.3" {
+			r.Proxied = true
+			s.records[id] = r
+		}
+	}
+	s.mu.Unlock()
+
+	if diff := check(rec); len(diff) != 1 || diff[0] != "proxied is true for 10.0.0.3, want false" {
+		t.Errorf("diff of proxied record = %v", diff)
+	}
+
+	if err := c.Upsert(ctx, rec); err != nil {
+		t.Fatalf("upsert: %s", err)
+	}
+
+	if got, want := s.calls(), []string{"PUT 10.0.0.3"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("update calls = %v, want %v", got, want)
+	}
+
+	if err := c.Delete(ctx, rec); err != nil {
+		t.Fatalf("delete: %s", err)
+	}
+
+	if diff := check(NewRecord(nil, "A", "www.example.com", "example.com")); len(diff) != 0 {
+		t.Errorf("diff after delete = %v", diff)
+	}
+}
+
+func TestCloudflareRecordsErrors(t *testing.T) {
+	_, c := newCloudflareStub(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		c    *CloudflareRecords
+		rec  *Record
+		err  string
+	}{
+		{
+			name: "unknown zone",
+			c:    c,
+			rec:  NewRecord([]string{"10.0.0.1"}, "A", "www.example.net", "example.net"),
+			err:  `cloudflare zone of domain "example.net" not found`,
+		},
+		{
+			name: "rejected token",
+			c:    NewCloudflareRecords(c.Endpoint, "other", false),
+			rec:  NewRecord([]string{"10.0.0.1"}, "A", "www.example.com", "example.com"),
+			err:  "cloudflare api status 403: 403 Authentication error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.c.Upsert(ctx, tt.rec)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("upsert error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
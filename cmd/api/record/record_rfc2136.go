@@ -0,0 +1,780 @@
+package record
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNS message constants not defined by the dnsmessage package.
+const (
+	opCodeUpdate dnsmessage.OpCode = 5
+	typeTSIG     dnsmessage.Type   = 250
+)
+
+// defaultExchangeTimeout bounds a DNS message exchange when the context has no deadline.
+const defaultExchangeTimeout = 10 * time.Second
+
+// tsigFudge is the permitted difference in seconds between the clocks of rcstate and the DNS server.
+const tsigFudge = 300
+
+// RFC2136Records manages DNS records with dynamic update messages (RFC 2136) sent to an authoritative DNS server.
+// The messages are signed with TSIG (RFC 8945) if a key is provided.
+// It implements the Provider interface.
+type RFC2136Records struct {
+	Server string
+	TSIG   TSIG
+}
+
+// TSIG stores the key used to sign the dynamic update messages.
+type TSIG struct {
+	// Algorithm is one of hmac-sha1, hmac-sha256 (default), or hmac-sha512.
+	Algorithm string
+
+	// Name is the name of the key, as configured on the DNS server.
+	Name string
+
+	// Secret is the base64 encoded secret of the key.
+	Secret string
+
+	// SecretFile is the path of a file with the base64 encoded secret of the key, read if Secret is empty.
+	SecretFile string
+}
+
+// tsigSecretEnv is the environment variable read for the TSIG secret if neither the secret nor its file is provided.
+const tsigSecretEnv = "RCSTATE_TSIG_SECRET"
+
+// loadSecret sets the secret of the key from its file, or from the environment variable.
+func (t *TSIG) loadSecret() error {
+	if t.Name == "" || t.Secret != "" {
+		return nil
+	}
+
+	if t.SecretFile != "" {
+		b, err := os.ReadFile(t.SecretFile)
+		if err != nil {
+			return fmt.Errorf("read TSIG secret: %w", err)
+		}
+		t.Secret = strings.TrimSpace(string(b))
+		return nil
+	}
+
+	t.Secret = os.Getenv(tsigSecretEnv)
+	if t.Secret == "" {
+		return fmt.Errorf("TSIG key %q requires a secret, a secret file, or %s", t.Name, tsigSecretEnv)
+	}
+
+	return nil
+}
+
+// NewRFC2136Records returns a RFC2136Records struct with provided server address and TSIG key.
+// The port 53 is used if the server address does not have a port.
+func NewRFC2136Records(server string, key TSIG) *RFC2136Records {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+
+	return &RFC2136Records{
+		Server: server,
+		TSIG:   key,
+	}
+}
+
+// Upsert replaces the record set with the same name and type with the DNS record.
+func (rr *RFC2136Records) Upsert(ctx context.Context, r *Record) error {
+	return rr.update(ctx, r, true)
+}
+
+// Delete deletes the record set with the same name and type as the DNS record.
+func (rr *RFC2136Records) Delete(ctx context.Context, r *Record) error {
+	return rr.update(ctx, r, false)
+}
+
+// Lookup queries the DNS server for the record set with the same name and type,
+// and returns it, or nil if there is none.
+func (rr *RFC2136Records) Lookup(ctx context.Context, r *Record) (*Record, error) {
+	name, typ, err := questionOf(r)
+	if err != nil {
+		return nil, err
+	}
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: newID()})
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+
+	if err := b.Question(dnsmessage.Question{Name: name, Type: typ, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+
+	msg, err := b.Finish()
+	if err != nil {
+		return nil, fmt.Errorf("build query: %w", err)
+	}
+
+	resp, err := rr.exchange(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var p dnsmessage.Parser
+
+	h, err := p.Start(resp)
+	if err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+
+	switch h.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("query %s %s: %s", r.Zone, r.Type, h.RCode)
+	}
+
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+
+	var existing *Record
+
+	for {
+		ah, err := p.AnswerHeader()
+		if err == dnsmessage.ErrSectionDone {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse response: %w", err)
+		}
+
+		if ah.Type != typ || !strings.EqualFold(ah.Name.String(), name.String()) {
+			if err := p.SkipAnswer(); err != nil {
+				return nil, fmt.Errorf("parse response: %w", err)
+			}
+			continue
+		}
+
+		value, err := answerValue(&p, typ)
+		if err != nil {
+			return nil, fmt.Errorf("parse response: %w", err)
+		}
+
+		if existing == nil {
+			existing = &Record{Domain: r.Domain, TTL: int64(ah.TTL), Type: r.Type, Zone: r.Zone}
+		}
+
+		existing.IP = append(existing.IP, value)
+	}
+
+	return existing, nil
+}
+
+// update sends a dynamic update message that deletes the record set with the same name and type as the record,
+// and adds the record if add is true.
+func (rr *RFC2136Records) update(ctx context.Context, r *Record, add bool) error {
+	name, typ, err := questionOf(r)
+	if err != nil {
+		return err
+	}
+
+	zone, err := dnsmessage.NewName(fqdn(r.Domain))
+	if err != nil {
+		return fmt.Errorf("zone name %q: %w", r.Domain, err)
+	}
+
+	id := newID()
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, OpCode: opCodeUpdate})
+
+	// The question section holds the zone, and the authority section holds the updates.
+	if err := b.StartQuestions(); err != nil {
+		return err
+	}
+
+	if err := b.Question(dnsmessage.Question{Name: zone, Type: dnsmessage.TypeSOA, Class: dnsmessage.ClassINET}); err != nil {
+		return fmt.Errorf("build update: %w", err)
+	}
+
+	if err := b.StartAuthorities(); err != nil {
+		return err
+	}
+
+	deleteSet := dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassANY}
+	if err := b.UnknownResource(deleteSet, dnsmessage.UnknownResource{Type: typ}); err != nil {
+		return fmt.Errorf("build update: %w", err)
+	}
+
+	if add {
+		for _, value := range r.IP {
+			h := dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: uint32(r.ttl())}
+			if err := addResource(&b, h, typ, value); err != nil {
+				return fmt.Errorf("build update: %w", err)
+			}
+		}
+	}
+
+	msg, err := b.Finish()
+	if err != nil {
+		return fmt.Errorf("build update: %w", err)
+	}
+
+	var mac []byte
+
+	if rr.TSIG.Name != "" {
+		if msg, mac, err = rr.TSIG.sign(msg, id, time.Now()); err != nil {
+			return fmt.Errorf("sign update: %w", err)
+		}
+	}
+
+	resp, err := rr.exchange(ctx, msg)
+	if err != nil {
+		return err
+	}
+
+	if rr.TSIG.Name != "" {
+		if err := rr.TSIG.verify(resp, mac, time.Now()); err != nil {
+			return fmt.Errorf("verify response: %w", err)
+		}
+	}
+
+	var p dnsmessage.Parser
+
+	h, err := p.Start(resp)
+	if err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+
+	if h.RCode != dnsmessage.RCodeSuccess {
+		return fmt.Errorf("update %s %s: %s", r.Zone, r.Type, h.RCode)
+	}
+
+	return nil
+}
+
+// exchange sends the message to the DNS server and returns the response.
+// The message is sent over UDP, and again over TCP if the response is truncated.
+func (rr *RFC2136Records) exchange(ctx context.Context, msg []byte) ([]byte, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultExchangeTimeout)
+		defer cancel()
+	}
+
+	resp, err := exchangeOver(ctx, "udp", rr.Server, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var p dnsmessage.Parser
+
+	h, err := p.Start(resp)
+	if err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+
+	if !h.Truncated {
+		return resp, nil
+	}
+
+	return exchangeOver(ctx, "tcp", rr.Server, msg)
+}
+
+// exchangeOver sends the message to the server over the network, and returns the response with the same ID.
+func exchangeOver(ctx context.Context, network string, server string, msg []byte) ([]byte, error) {
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, fmt.Errorf("dial dns server: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, fmt.Errorf("set deadline: %w", err)
+		}
+	}
+
+	id := binary.BigEndian.Uint16(msg)
+
+	if network == "tcp" {
+		msg = append(binary.BigEndian.AppendUint16(nil, uint16(len(msg))), msg...)
+	}
+
+	if _, err := conn.Write(msg); err != nil {
+		return nil, fmt.Errorf("send dns message: %w", err)
+	}
+
+	for {
+		var resp []byte
+
+		if network == "tcp" {
+			var l [2]byte
+			if _, err := io.ReadFull(conn, l[:]); err != nil {
+				return nil, fmt.Errorf("read dns response: %w", err)
+			}
+
+			resp = make([]byte, binary.BigEndian.Uint16(l[:]))
+			if _, err := io.ReadFull(conn, resp); err != nil {
+				return nil, fmt.Errorf("read dns response: %w", err)
+			}
+		} else {
+			buf := make([]byte, 65535)
+			n, err := conn.Read(buf)
+			if err != nil {
+				return nil, fmt.Errorf("read dns response: %w", err)
+			}
+			resp = buf[:n]
+		}
+
+		// Responses to other messages are ignored.
+		if len(resp) >= 12 && binary.BigEndian.Uint16(resp) == id {
+			return resp, nil
+		}
+	}
+}
+
+// questionOf returns the name and type of the record as DNS message fields.
+func questionOf(r *Record) (dnsmessage.Name, dnsmessage.Type, error) {
+	name, err := dnsmessage.NewName(fqdn(r.Zone))
+	if err != nil {
+		return name, 0, fmt.Errorf("record name %q: %w", r.Zone, err)
+	}
+
+	switch strings.ToUpper(r.Type) {
+	case "A":
+		return name, dnsmessage.TypeA, nil
+	case "AAAA":
+		return name, dnsmessage.TypeAAAA, nil
+	case "CNAME":
+		return name, dnsmessage.TypeCNAME, nil
+	default:
+		return name, 0, fmt.Errorf("record type %q is not supported", r.Type)
+	}
+}
+
+// addResource adds a resource record with the value to the message.
+func addResource(b *dnsmessage.Builder, h dnsmessage.ResourceHeader, typ dnsmessage.Type, value string) error {
+	switch typ {
+	case dnsmessage.TypeA:
+		ip, err := netip.ParseAddr(value)
+		if err != nil || !ip.Is4() {
+			return fmt.Errorf("invalid IPv4 address %q", value)
+		}
+		return b.AResource(h, dnsmessage.AResource{A: ip.As4()})
+	case dnsmessage.TypeAAAA:
+		ip, err := netip.ParseAddr(value)
+		if err != nil || !ip.Is6() {
+			return fmt.Errorf("invalid IPv6 address %q", value)
+		}
+		return b.AAAAResource(h, dnsmessage.AAAAResource{AAAA: ip.As16()})
+	case dnsmessage.TypeCNAME:
+		target, err := dnsmessage.NewName(fqdn(value))
+		if err != nil {
+			return fmt.Errorf("invalid name %q: %w", value, err)
+		}
+		return b.CNAMEResource(h, dnsmessage.CNAMEResource{CNAME: target})
+	default:
+		return fmt.Errorf("record type %s is not supported", typ)
+	}
+}
+
+// answerValue returns the value of the current answer of the parser.
+func answerValue(p *dnsmessage.Parser, typ dnsmessage.Type) (string, error) {
+	switch typ {
+	case dnsmessage.TypeA:
+		r, err := p.AResource()
+		if err != nil {
+			return "", err
+		}
+		return netip.AddrFrom4(r.A).String(), nil
+	case dnsmessage.TypeAAAA:
+		r, err := p.AAAAResource()
+		if err != nil {
+			return "", err
+		}
+		return netip.AddrFrom16(r.AAAA).String(), nil
+	default:
+		r, err := p.CNAMEResource()
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(r.CNAME.String(), "."), nil
+	}
+}
+
+// newID returns a random message ID.
+func newID() uint16 {
+	var b [2]byte
+	_, _ = rand.Read(b[:])
+
+	return binary.BigEndian.Uint16(b[:])
+}
+
+// algorithm returns the name of the TSIG algorithm, and the hash function of its HMAC.
+func (t TSIG) algorithm() (string, func() hash.Hash, error) {
+	switch strings.ToLower(strings.TrimSuffix(t.Algorithm, ".")) {
+	case "hmac-sha1":
+		return "hmac-sha1.", sha1.New, nil
+	case "", "hmac-sha256":
+		return "hmac-sha256.", sha256.New, nil
+	case "hmac-sha512":
+		return "hmac-sha512.", sha512.New, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported TSIG algorithm %q", t.Algorithm)
+	}
+}
+
+// mac returns the HMAC of the data with the secret of the key.
+func (t TSIG) mac(data ...[]byte) ([]byte, error) {
+	_, h, err := t.algorithm()
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := base64.StdEncoding.DecodeString(t.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("decode TSIG secret: %w", err)
+	}
+
+	m := hmac.New(h, secret)
+	for _, d := range data {
+		m.Write(d)
+	}
+
+	return m.Sum(nil), nil
+}
+
+// tsigRecord stores the fields of a TSIG record.
+type tsigRecord struct {
+	algorithm  string
+	timeSigned uint64
+	fudge      uint16
+	mac        []byte
+	originalID uint16
+	err        uint16
+	other      []byte
+}
+
+// variables returns the TSIG variables covered by the MAC, in wire format.
+func (t TSIG) variables(r tsigRecord) []byte {
+	b := packName(t.Name)
+	b = binary.BigEndian.AppendUint16(b, uint16(dnsmessage.ClassANY))
+	b = binary.BigEndian.AppendUint32(b, 0)
+	b = append(b, packName(r.algorithm)...)
+	b = appendUint48(b, r.timeSigned)
+	b = binary.BigEndian.AppendUint16(b, r.fudge)
+	b = binary.BigEndian.AppendUint16(b, r.err)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(r.other)))
+
+	return append(b, r.other...)
+}
+
+// sign appends a TSIG record to the message, and returns the signed message and its MAC.
+func (t TSIG) sign(msg []byte, id uint16, now time.Time) ([]byte, []byte, error) {
+	algorithm, _, err := t.algorithm()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := tsigRecord{
+		algorithm:  algorithm,
+		timeSigned: uint64(now.Unix()),
+		fudge:      tsigFudge,
+		originalID: id,
+	}
+
+	if r.mac, err = t.mac(msg, t.variables(r)); err != nil {
+		return nil, nil, err
+	}
+
+	rdata := packName(r.algorithm)
+	rdata = appendUint48(rdata, r.timeSigned)
+	rdata = binary.BigEndian.AppendUint16(rdata, r.fudge)
+	rdata = binary.BigEndian.AppendUint16(rdata, uint16(len(r.mac)))
+	rdata = append(rdata, r.mac...)
+	rdata = binary.BigEndian.AppendUint16(rdata, r.originalID)
+	rdata = binary.BigEndian.AppendUint16(rdata, r.err)
+	rdata = binary.BigEndian.AppendUint16(rdata, 0)
+
+	signed := append([]byte{}, msg...)
+	signed = append(signed, packName(t.Name)...)
+	signed = binary.BigEndian.AppendUint16(signed, uint16(typeTSIG))
+	signed = binary.BigEndian.AppendUint16(signed, uint16(dnsmessage.ClassANY))
+	signed = binary.BigEndian.AppendUint32(signed, 0)
+	signed = binary.BigEndian.AppendUint16(signed, uint16(len(rdata)))
+	signed = append(signed, rdata...)
+
+	// Increment the count of the additional section.
+	binary.BigEndian.PutUint16(signed[10:], binary.BigEndian.Uint16(signed[10:])+1)
+
+	return signed, r.mac, nil
+}
+
+// verify checks the TSIG record of the response to a request signed with the request MAC.
+func (t TSIG) verify(resp []byte, requestMAC []byte, now time.Time) error {
+	var p dnsmessage.Parser
+
+	h, err := p.Start(resp)
+	if err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+
+	// Errors of the server, such as a rejected signature, may be sent without a TSIG record.
+	if binary.BigEndian.Uint16(resp[10:]) == 0 {
+		return fmt.Errorf("response is not signed, rcode %s", h.RCode)
+	}
+
+	off, err := lastRecordOffset(resp)
+	if err != nil {
+		return err
+	}
+
+	if err := p.SkipAllQuestions(); err != nil {
+		return err
+	}
+	if err := p.SkipAllAnswers(); err != nil {
+		return err
+	}
+	if err := p.SkipAllAuthorities(); err != nil {
+		return err
+	}
+
+	var rh dnsmessage.ResourceHeader
+	var data []byte
+
+	for {
+		ah, err := p.AdditionalHeader()
+		if err == dnsmessage.ErrSectionDone {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("parse response: %w", err)
+		}
+
+		u, err := p.UnknownResource()
+		if err != nil {
+			return fmt.Errorf("parse response: %w", err)
+		}
+
+		rh, data = ah, u.Data
+	}
+
+	if rh.Type != typeTSIG {
+		return fmt.Errorf("response is not signed, rcode %s", h.RCode)
+	}
+
+	if !strings.EqualFold(strings.TrimSuffix(rh.Name.String(), "."), strings.TrimSuffix(t.Name, ".")) {
+		return fmt.Errorf("response is signed with key %q", rh.Name.String())
+	}
+
+	r, err := parseTSIG(data)
+	if err != nil {
+		return err
+	}
+
+	if r.err != 0 {
+		return fmt.Errorf("TSIG error %s", tsigError(r.err))
+	}
+
+	// The MAC covers the response without the TSIG record, with the original ID.
+	unsigned := append([]byte{}, resp[:off]...)
+	binary.BigEndian.PutUint16(unsigned, r.originalID)
+	binary.BigEndian.PutUint16(unsigned[10:], binary.BigEndian.Uint16(unsigned[10:])-1)
+
+	prefix := binary.BigEndian.AppendUint16(nil, uint16(len(requestMAC)))
+
+	expected, err := t.mac(prefix, requestMAC, unsigned, t.variables(r))
+	if err != nil {
+		return err
+	}
+
+	if !hmac.Equal(expected, r.mac) {
+		return fmt.Errorf("TSIG signature of the response does not match")
+	}
+
+	signed := int64(r.timeSigned)
+	if d := now.Unix() - signed; d > int64(r.fudge) || -d > int64(r.fudge) {
+		return fmt.Errorf("TSIG time of the response is outside of the permitted %ds", r.fudge)
+	}
+
+	return nil
+}
+
+// parseTSIG returns the fields of the TSIG record data.
+func parseTSIG(data []byte) (tsigRecord, error) {
+	var r tsigRecord
+
+	algorithm, off, err := unpackName(data, 0)
+	if err != nil {
+		return r, fmt.Errorf("parse TSIG algorithm: %w", err)
+	}
+	r.algorithm = algorithm
+
+	if len(data) < off+10 {
+		return r, fmt.Errorf("parse TSIG: short record")
+	}
+
+	r.timeSigned = uint64(binary.BigEndian.Uint16(data[off:]))<<32 | uint64(binary.BigEndian.Uint32(data[off+2:]))
+	r.fudge = binary.BigEndian.Uint16(data[off+6:])
+	size := int(binary.BigEndian.Uint16(data[off+8:]))
+	off += 10
+
+	if len(data) < off+size+6 {
+		return r, fmt.Errorf("parse TSIG: short record")
+	}
+
+	r.mac = data[off : off+size]
+	off += size
+
+	r.originalID = binary.BigEndian.Uint16(data[off:])
+	r.err = binary.BigEndian.Uint16(data[off+2:])
+	otherLen := int(binary.BigEndian.Uint16(data[off+4:]))
+	off += 6
+
+	if len(data) < off+otherLen {
+		return r, fmt.Errorf("parse TSIG: short record")
+	}
+
+	r.other = data[off : off+otherLen]
+
+	return r, nil
+}
+
+// tsigError returns the name of a TSIG error code.
+func tsigError(code uint16) string {
+	switch code {
+	case 16:
+		return "BADSIG"
+	case 17:
+		return "BADKEY"
+	case 18:
+		return "BADTIME"
+	case 22:
+		return "BADTRUNC"
+	default:
+		return fmt.Sprintf("%d", code)
+	}
+}
+
+// lastRecordOffset returns the offset of the last resource record of the message.
+func lastRecordOffset(msg []byte) (int, error) {
+	if len(msg) < 12 {
+		return 0, fmt.Errorf("short dns message")
+	}
+
+	questions := int(binary.BigEndian.Uint16(msg[4:]))
+	records := int(binary.BigEndian.Uint16(msg[6:])) + int(binary.BigEndian.Uint16(msg[8:])) + int(binary.BigEndian.Uint16(msg[10:]))
+
+	off := 12
+	var err error
+
+	for i := 0; i < questions; i++ {
+		if off, err = skipName(msg, off); err != nil {
+			return 0, err
+		}
+		off += 4
+	}
+
+	last := -1
+
+	for i := 0; i < records; i++ {
+		last = off
+
+		if off, err = skipName(msg, off); err != nil {
+			return 0, err
+		}
+
+		if len(msg) < off+10 {
+			return 0, fmt.Errorf("short dns message")
+		}
+
+		off += 10 + int(binary.BigEndian.Uint16(msg[off+8:]))
+	}
+
+	if last < 0 || off > len(msg) {
+		return 0, fmt.Errorf("dns message has no resource records")
+	}
+
+	return last, nil
+}
+
+// skipName returns the offset after the name at the offset of the message.
+func skipName(msg []byte, off int) (int, error) {
+	for {
+		if off >= len(msg) {
+			return 0, fmt.Errorf("short dns message")
+		}
+
+		l := int(msg[off])
+
+		switch {
+		case l == 0:
+			return off + 1, nil
+		case l&0xC0 == 0xC0:
+			return off + 2, nil
+		default:
+			off += l + 1
+		}
+	}
+}
+
+// packName returns the name in canonical wire format, lowercase and uncompressed.
+func packName(name string) []byte {
+	var b []byte
+
+	for _, label := range strings.Split(strings.TrimSuffix(strings.ToLower(name), "."), ".") {
+		if label == "" {
+			continue
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+
+	return append(b, 0)
+}
+
+// unpackName returns the uncompressed name at the offset of the data, and the offset after it.
+func unpackName(data []byte, off int) (string, int, error) {
+	var labels []string
+
+	for {
+		if off >= len(data) {
+			return "", 0, fmt.Errorf("short name")
+		}
+
+		l := int(data[off])
+		off++
+
+		if l == 0 {
+			return strings.Join(labels, ".") + ".", off, nil
+		}
+
+		if l&0xC0 != 0 || off+l > len(data) {
+			return "", 0, fmt.Errorf("invalid name")
+		}
+
+		labels = append(labels, string(data[off:off+l]))
+		off += l
+	}
+}
+
+// appendUint48 appends the lower 48 bits of v in big endian order.
+func appendUint48(b []byte, v uint64) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(v>>32))
+	return binary.BigEndian.AppendUint32(b, uint32(v))
+}
@@ -0,0 +1,638 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// testKey is the TSIG key of the tests, with the secret "secret-key-of-rcstate-test".
+var testKey = TSIG{
+	Algorithm: "hmac-sha256",
+	Name:      "rcstate-key.",
+	Secret:    "c2VjcmV0LWtleS1vZi1yY3N0YXRlLXRlc3Q=",
+}
+
+// testTime is the time signed in the TSIG vectors.
+var testTime = time.Unix(1700000000, 0)
+
+// The vectors are an update of zone example.com with ID 0x1234, signed with testKey at testTime,
+// and the response of the server. The MACs were computed independently of this package,
+// with the HMAC-SHA256 of the wire format of RFC 8945 section 4.3.
+const (
+	vectorRequest = "123428000001000000000000076578616d706c6503636f6d0000060001"
+
+	vectorSignedRequest = "123428000001000000000001076578616d706c6503636f6d0000060001" +
+		"0b726373746174652d6b65790000fa00ff00000000003d" +
+		"0b686d61632d7368613235360000006553f100012c0020" +
+		"bf1f7334b63c4b63aec78c24585b5d74551827f9220e97ad439f7f3777e2cde4" +
+		"123400000000"
+
+	vectorRequestMAC = "bf1f7334b63c4b63aec78c24585b5d74551827f9220e97ad439f7f3777e2cde4"
+
+	vectorSignedResponse = "1234a8000001000000000001076578616d706c6503636f6d0000060001" +
+		"0b726373746174652d6b65790000fa00ff00000000003d" +
+		"0b686d61632d7368613235360000006553f100012c0020" +
+		"c18f5afca6da3b64372469fafb3f10320af7eaac2e92353dbfe95d959379f76f" +
+		"123400000000"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("decode hex: %s", err)
+	}
+
+	return b
+}
+
+func TestTSIGSignVector(t *testing.T) {
+	signed, mac, err := testKey.sign(decodeHex(t, vectorRequest), 0x1234, testTime)
+	if err != nil {
+		t.Fatalf("sign: %s", err)
+	}
+
+	if got := hex.EncodeToString(mac); got != vectorRequestMAC {
+		t.Errorf("mac = %s, want %s", got, vectorRequestMAC)
+	}
+
+	if got := hex.EncodeToString(signed); got != vectorSignedRequest {
+		t.Errorf("signed request = %s, want %s", got, vectorSignedRequest)
+	}
+}
+
+func TestTSIGVerifyVector(t *testing.T) {
+	resp := decodeHex(t, vectorSignedResponse)
+	mac := decodeHex(t, vectorRequestMAC)
+
+	tests := []struct {
+		name string
+		key  TSIG
+		mac  []byte
+		now  time.Time
+		err  string
+	}{
+		{name: "valid", key: testKey, mac: mac, now: testTime},
+		{name: "within fudge", key: testKey, mac: mac, now: testTime.Add(tsigFudge * time.Second)},
+		{name: "outside fudge", key: testKey, mac: mac, now: testTime.Add((tsigFudge + 1) * time.Second), err: "TSIG time"},
+		{name: "other request", key: testKey, mac: bytes.Repeat([]byte{1}, 32), now: testTime, err: "does not match"},
+		{name: "other secret", key: TSIG{Name: testKey.Name, Secret: "b3RoZXI="}, mac: mac, now: testTime, err: "does not match"},
+		{name: "other key", key: TSIG{Name: "other.", Secret: testKey.Secret}, mac: mac, now: testTime, err: "signed with key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.key.verify(resp, tt.mac, tt.now)
+			if tt.err == "" && err != nil {
+				t.Errorf("verify: %s", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("verify error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestTSIGRoundTrip(t *testing.T) {
+	for _, algorithm := range []string{"hmac-sha1", "hmac-sha256", "hmac-sha512"} {
+		t.Run(algorithm, func(t *testing.T) {
+			key := testKey
+			key.Algorithm = algorithm
+
+			signed, mac, err := key.sign(decodeHex(t, vectorRequest), 0x1234, testTime)
+			if err != nil {
+				t.Fatalf("sign: %s", err)
+			}
+
+			resp, err := signResponse(key, signed, mac, testTime, dnsmessage.RCodeSuccess, nil)
+			if err != nil {
+				t.Fatalf("sign response: %s", err)
+			}
+
+			if err := key.verify(resp, mac, testTime); err != nil {
+				t.Errorf("verify: %s", err)
+			}
+
+			resp[len(resp)-10] ^= 0xff
+			if err := key.verify(resp, mac, testTime); err == nil {
+				t.Errorf("verify of a modified response succeeded")
+			}
+		})
+	}
+}
+
+func TestMalformedMessages(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  []byte
+	}{
+		{name: "empty"},
+		{name: "short header", msg: []byte{0x12, 0x34, 0x28}},
+		{name: "no records", msg: decodeHex(t, vectorRequest)},
+		{name: "question past end", msg: []byte{0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 1, 7, 'e', 'x'}},
+		{name: "record count past end", msg: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 1, 0, 1, 0, 0, 0, 0, 0, 0}},
+		{name: "rdata past end", msg: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 1, 0, 1, 0, 0, 0, 0, 0xff, 0xff}},
+		{name: "label past end", msg: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0x3f, 'a'}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := lastRecordOffset(tt.msg); err == nil {
+				t.Errorf("lastRecordOffset succeeded")
+			}
+
+			if err := testKey.verify(tt.msg, nil, testTime); err == nil {
+				t.Errorf("verify succeeded")
+			}
+		})
+	}
+}
+
+func TestMalformedNames(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		off  int
+	}{
+		{name: "empty"},
+		{name: "offset past end", data: []byte{0}, off: 1},
+		{name: "missing root label", data: []byte{3, 'c', 'o', 'm'}},
+		{name: "label past end", data: []byte{5, 'c', 'o', 'm', 0}},
+		{name: "compression pointer", data: []byte{3, 'c', 'o', 'm', 0xc0, 0}},
+		{name: "reserved label type", data: []byte{0x40, 'a', 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := unpackName(tt.data, tt.off); err == nil {
+				t.Errorf("unpackName succeeded")
+			}
+
+			if _, err := parseTSIG(tt.data); err == nil {
+				t.Errorf("parseTSIG succeeded")
+			}
+		})
+	}
+}
+
+func TestTruncatedResponse(t *testing.T) {
+	resp := decodeHex(t, vectorSignedResponse)
+	mac := decodeHex(t, vectorRequestMAC)
+
+	// Every prefix of the signed response is rejected.
+	for i := 0; i < len(resp); i++ {
+		if _, err := lastRecordOffset(resp[:i]); err == nil && i < 12 {
+			t.Errorf("lastRecordOffset of %d bytes succeeded", i)
+		}
+
+		if err := testKey.verify(resp[:i], mac, testTime); err == nil {
+			t.Errorf("verify of %d bytes succeeded", i)
+		}
+	}
+
+	// Changed bytes of the signed response do not panic.
+	for i := range resp {
+		changed := append([]byte{}, resp...)
+		changed[i] ^= 0xff
+		_ = testKey.verify(changed, mac, testTime)
+	}
+}
+
+func TestPackName(t *testing.T) {
+	tests := []struct {
+		name string
+		want []byte
+	}{
+		{name: "", want: []byte{0}},
+		{name: ".", want: []byte{0}},
+		{name: "Example.COM", want: []byte{7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0}},
+		{name: "example.com.", want: []byte{7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0}},
+	}
+
+	for _, tt := range tests {
+		got := packName(tt.name)
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("packName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+
+		name, off, err := unpackName(got, 0)
+		if err != nil || off != len(got) {
+			t.Errorf("unpackName(%v) = %q, %d, %v", got, name, off, err)
+		}
+	}
+}
+
+// signResponse returns the response with the answers to the signed request,
+// signed with the key as a DNS server signs it, or unsigned if the key has no name.
+func signResponse(key TSIG, req []byte, requestMAC []byte, now time.Time, rcode dnsmessage.RCode, answers func(*dnsmessage.Builder) error) ([]byte, error) {
+	var p dnsmessage.Parser
+
+	h, err := p.Start(req)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := p.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, OpCode: h.OpCode, RCode: rcode})
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(q); err != nil {
+		return nil, err
+	}
+
+	if answers != nil {
+		if err := b.StartAnswers(); err != nil {
+			return nil, err
+		}
+		if err := answers(&b); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := b.Finish()
+	if err != nil || key.Name == "" {
+		return resp, err
+	}
+
+	algorithm, _, err := key.algorithm()
+	if err != nil {
+		return nil, err
+	}
+
+	r := tsigRecord{algorithm: algorithm, timeSigned: uint64(now.Unix()), fudge: tsigFudge, originalID: h.ID}
+
+	prefix := binary.BigEndian.AppendUint16(nil, uint16(len(requestMAC)))
+	if r.mac, err = key.mac(prefix, requestMAC, resp, key.variables(r)); err != nil {
+		return nil, err
+	}
+
+	rdata := packName(r.algorithm)
+	rdata = appendUint48(rdata, r.timeSigned)
+	rdata = binary.BigEndian.AppendUint16(rdata, r.fudge)
+	rdata = binary.BigEndian.AppendUint16(rdata, uint16(len(r.mac)))
+	rdata = append(rdata, r.mac...)
+	rdata = binary.BigEndian.AppendUint16(rdata, r.originalID)
+	rdata = binary.BigEndian.AppendUint32(rdata, 0)
+
+	resp = append(resp, packName(key.Name)...)
+	resp = binary.BigEndian.AppendUint16(resp, uint16(typeTSIG))
+	resp = binary.BigEndian.AppendUint16(resp, uint16(dnsmessage.ClassANY))
+	resp = binary.BigEndian.AppendUint32(resp, 0)
+	resp = binary.BigEndian.AppendUint16(resp, uint16(len(rdata)))
+	resp = append(resp, rdata...)
+	binary.BigEndian.PutUint16(resp[10:], 1)
+
+	return resp, nil
+}
+
+// stubServer is an in-process authoritative DNS server, listening on the same UDP and TCP port.
+// It stores the record sets changed by dynamic updates, and answers queries from them.
+type stubServer struct {
+	addr string
+	key  TSIG
+
+	mu       sync.Mutex
+	sets     map[string][]string
+	tcp      int
+	truncate bool
+	udp      int
+}
+
+func newStubServer(t *testing.T, key TSIG) *stubServer {
+	t.Helper()
+
+	s := &stubServer{key: key, sets: map[string][]string{}}
+
+	var pc net.PacketConn
+	var ln net.Listener
+
+	for i := 0; ln == nil; i++ {
+		var err error
+
+		if pc, err = net.ListenPacket("udp", "127.0.0.1:0"); err != nil {
+			t.Fatalf("listen udp: %s", err)
+		}
+
+		if ln, err = net.Listen("tcp", pc.LocalAddr().String()); err != nil {
+			pc.Close()
+			if i == 10 {
+				t.Fatalf("listen tcp: %s", err)
+			}
+		}
+	}
+
+	s.addr = pc.LocalAddr().String()
+
+	t.Cleanup(func() {
+		pc.Close()
+		ln.Close()
+	})
+
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := s.handle(buf[:n], "udp"); resp != nil {
+				_, _ = pc.WriteTo(resp, addr)
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serveTCP(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *stubServer) serveTCP(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		var l [2]byte
+		if _, err := io.ReadFull(conn, l[:]); err != nil {
+			return
+		}
+
+		msg := make([]byte, binary.BigEndian.Uint16(l[:]))
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return
+		}
+
+		resp := s.handle(msg, "tcp")
+		if resp == nil {
+			return
+		}
+
+		_, _ = conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(resp))), resp...))
+	}
+}
+
+// setKey returns the key of a record set in the store.
+func setKey(name dnsmessage.Name, typ dnsmessage.Type) string {
+	return strings.ToLower(name.String()) + " " + typ.String()
+}
+
+// handle returns the response to the message.
+func (s *stubServer) handle(msg []byte, network string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if network == "udp" {
+		s.udp++
+	} else {
+		s.tcp++
+	}
+
+	var p dnsmessage.Parser
+
+	h, err := p.Start(msg)
+	if err != nil {
+		return nil
+	}
+
+	q, err := p.Question()
+	if err != nil {
+		return nil
+	}
+
+	if h.OpCode != opCodeUpdate {
+		values := s.sets[setKey(q.Name, q.Type)]
+		truncated := s.truncate && network == "udp"
+
+		resp, err := signResponse(TSIG{}, msg, nil, time.Now(), dnsmessage.RCodeSuccess, func(b *dnsmessage.Builder) error {
+			if truncated {
+				return nil
+			}
+			for _, v := range values {
+				if err := addResource(b, dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}, q.Type, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil
+		}
+
+		if truncated {
+			resp[2] |= 0x02
+		}
+
+		return resp
+	}
+
+	mac, ok := s.verifyRequest(msg)
+	if !ok {
+		resp, _ := signResponse(TSIG{}, msg, nil, time.Now(), dnsmessage.RCodeRefused, nil)
+		return resp
+	}
+
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil
+	}
+	if err := p.SkipAllAnswers(); err != nil {
+		return nil
+	}
+
+	for {
+		ah, err := p.AuthorityHeader()
+		if errors.Is(err, dnsmessage.ErrSectionDone) {
+			break
+		}
+		if err != nil {
+			return nil
+		}
+
+		key := setKey(ah.Name, ah.Type)
+
+		if ah.Class == dnsmessage.ClassANY {
+			delete(s.sets, key)
+			if err := p.SkipAuthority(); err != nil {
+				return nil
+			}
+			continue
+		}
+
+		value, err := answerValue(&p, ah.Type)
+		if err != nil {
+			return nil
+		}
+		s.sets[key] = append(s.sets[key], value)
+	}
+
+	resp, _ := signResponse(s.key, msg, mac, time.Now(), dnsmessage.RCodeSuccess, nil)
+
+	return resp
+}
+
+// verifyRequest checks the TSIG record of a request if the server has a key, and returns the MAC of the request.
+func (s *stubServer) verifyRequest(msg []byte) ([]byte, bool) {
+	if s.key.Name == "" {
+		return nil, true
+	}
+
+	off, err := lastRecordOffset(msg)
+	if err != nil {
+		return nil, false
+	}
+
+	rdata, err := skipName(msg, off)
+	if err != nil || len(msg) < rdata+10 {
+		return nil, false
+	}
+
+	r, err := parseTSIG(msg[rdata+10:])
+	if err != nil {
+		return nil, false
+	}
+
+	unsigned := append([]byte{}, msg[:off]...)
+	binary.BigEndian.PutUint16(unsigned[10:], binary.BigEndian.Uint16(unsigned[10:])-1)
+
+	expected, err := s.key.mac(unsigned, s.key.variables(r))
+	if err != nil || !hmac.Equal(expected, r.mac) {
+		return nil, false
+	}
+
+	return r.mac, true
+}
+
+func TestRFC2136Records(t *testing.T) {
+	tests := []struct {
+		name string
+		key  TSIG
+	}{
+		{name: "unsigned"},
+		{name: "signed", key: testKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStubServer(t, tt.key)
+			rr := NewRFC2136Records(s.addr, tt.key)
+			ctx := context.Background()
+
+			lookup := func(typ string) []string {
+				t.Helper()
+
+				found, err := rr.Lookup(ctx, NewRecord(nil, typ, "www.example.com", "example.com"))
+				if err != nil {
+					t.Fatalf("lookup: %s", err)
+				}
+				if found == nil {
+					return nil
+				}
+
+				return found.IP
+			}
+
+			if err := rr.Upsert(ctx, NewRecord([]string{"10.0.0.1", "10.0.0.2"}, "A", "www.example.com", "example.com")); err != nil {
+				t.Fatalf("upsert: %s", err)
+			}
+
+			if got, want := lookup("A"), []string{"10.0.0.1", "10.0.0.2"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("record after upsert = %v, want %v", got, want)
+			}
+
+			if err := rr.Upsert(ctx, NewRecord([]string{"10.0.0.3"}, "A", "www.example.com", "example.com")); err != nil {
+				t.Fatalf("upsert: %s", err)
+			}
+
+			if got, want := lookup("A"), []string{"10.0.0.3"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("record after replace = %v, want %v", got, want)
+			}
+
+			if err := rr.Upsert(ctx, NewRecord([]string{"maintenance.example.net"}, "CNAME", "www.example.com", "example.com")); err != nil {
+				t.Fatalf("upsert: %s", err)
+			}
+
+			if got, want := lookup("CNAME"), []string{"maintenance.example.net"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("CNAME record = %v, want %v", got, want)
+			}
+
+			for _, typ := range []string{"A", "CNAME"} {
+				if err := rr.Delete(ctx, NewRecord(nil, typ, "www.example.com", "example.com")); err != nil {
+					t.Fatalf("delete: %s", err)
+				}
+
+				if got := lookup(typ); got != nil {
+					t.Errorf("%s record after delete = %v, want none", typ, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRFC2136RecordsRejectedKey(t *testing.T) {
+	s := newStubServer(t, testKey)
+	rr := NewRFC2136Records(s.addr, TSIG{Name: testKey.Name, Secret: "b3RoZXI="})
+
+	err := rr.Upsert(context.Background(), NewRecord([]string{"10.0.0.1"}, "A", "www.example.com", "example.com"))
+	if err == nil || !strings.Contains(err.Error(), "response is not signed") {
+		t.Errorf("upsert error = %v, want an unsigned response", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.sets) != 0 {
+		t.Errorf("records = %v, want none", s.sets)
+	}
+}
+
+func TestRFC2136RecordsTruncated(t *testing.T) {
+	s := newStubServer(t, TSIG{})
+	rr := NewRFC2136Records(s.addr, TSIG{})
+
+	want := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+
+	s.mu.Lock()
+	s.sets["www.example.com. TypeA"] = want
+	s.truncate = true
+	s.mu.Unlock()
+
+	found, err := rr.Lookup(context.Background(), NewRecord(nil, "A", "www.example.com", "example.com"))
+	if err != nil {
+		t.Fatalf("lookup: %s", err)
+	}
+
+	if found == nil || !reflect.DeepEqual(found.IP, want) {
+		t.Errorf("record = %v, want %v", found, want)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.udp != 1 || s.tcp != 1 {
+		t.Errorf("queries over udp %d, tcp %d, want 1 each", s.udp, s.tcp)
+	}
+}
@@ -0,0 +1,208 @@
+package record
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// Route53Records manages DNS records in Route 53 DNS service.
+// It implements the Provider interface.
+type Route53Records struct{}
+
+// NewRoute53Records returns a Route53Records struct.
+func NewRoute53Records() *Route53Records {
+	return &Route53Records{}
+}
+
+// Upsert creates or replaces the DNS record, and waits until the change is in sync.
+func (rr *Route53Records) Upsert(ctx context.Context, r *Record) error {
+	return rr.change(ctx, r, "UPSERT")
+}
+
+// Delete deletes the DNS record, and waits until the change is in sync.
+// The IP addresses and the TTL of the record must match the existing record.
+func (rr *Route53Records) Delete(ctx context.Context, r *Record) error {
+	return rr.change(ctx, r, "DELETE")
+}
+
+// Lookup returns the existing DNS record with the same name and type, or nil if there is none.
+func (rr *Route53Records) Lookup(ctx context.Context, r *Record) (*Record, error) {
+	svc, zoneID, err := route53Zone(ctx, r.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    zoneID,
+		MaxItems:        aws.String("1"),
+		StartRecordName: aws.String(r.Zone),
+		StartRecordType: aws.String(r.Type),
+	}
+
+	list, err := svc.ListResourceRecordSetsWithContext(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("list record sets: %w", err)
+	}
+
+	for _, set := range list.ResourceRecordSets {
+		if !sameRoute53Name(aws.StringValue(set.Name), r.Zone) || !strings.EqualFold(aws.StringValue(set.Type), r.Type) {
+			continue
+		}
+
+		existing := &Record{
+			Domain: r.Domain,
+			TTL:    aws.Int64Value(set.TTL),
+			Type:   r.Type,
+			Zone:   r.Zone,
+		}
+
+		for _, rr := range set.ResourceRecords {
+			existing.IP = append(existing.IP, aws.StringValue(rr.Value))
+		}
+
+		return existing, nil
+	}
+
+	return nil, nil
+}
+
+// sameRoute53Name returns whether the record set name returned by Route 53 is the name of the record.
+// Route 53 returns names in lowercase, with the wildcard character escaped as \052.
+func sameRoute53Name(setName string, name string) bool {
+	setName = strings.ReplaceAll(strings.TrimSuffix(setName, "."), `\052`, "*")
+
+	return strings.EqualFold(setName, strings.TrimSuffix(name, "."))
+}
+
+// change applies the action on the DNS record, and waits until the change is in sync.
+func (rr *Route53Records) change(ctx context.Context, r *Record, action string) error {
+	svc, zoneID, err := route53Zone(ctx, r.Domain)
+	if err != nil {
+		return err
+	}
+
+	var ipList []*route53.ResourceRecord
+
+	for _, v := range r.IP {
+		ipList = append(ipList, &route53.ResourceRecord{Value: aws.String(v)})
+	}
+
+	params := &route53.ChangeResourceRecordSetsInput{
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{
+				{
+					Action: aws.String(action),
+					ResourceRecordSet: &route53.ResourceRecordSet{
+						Name:            aws.String(r.Zone),
+						Type:            aws.String(r.Type),
+						ResourceRecords: ipList,
+						TTL:             aws.Int64(r.ttl()),
+					},
+				},
+			},
+			Comment: aws.String("Update record to reflect new IP address for a system"),
+		},
+		HostedZoneId: zoneID,
+	}
+
+	resp, err := svc.ChangeResourceRecordSetsWithContext(ctx, params)
+	if err != nil {
+		return fmt.Errorf("resp: %w", err)
+	}
+
+	changeID := &route53.GetChangeInput{
+		Id: resp.ChangeInfo.Id,
+	}
+
+	return checkChange(ctx, svc, changeID)
+}
+
+// route53Zone returns a Route 53 client, and the ID of the hosted zone of the domain.
+func route53Zone(ctx context.Context, domain string) (*route53.Route53, *string, error) {
+	if err := awsCredentials(); err != nil {
+		return nil, nil, fmt.Errorf("aws credentials: %w", err)
+	}
+
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, nil, fmt.Errorf("new session: %w", err)
+	}
+
+	svc := route53.New(sess)
+
+	hostedZoneNameInput := &route53.ListHostedZonesByNameInput{
+		DNSName: &domain,
+	}
+
+	list, err := svc.ListHostedZonesByNameWithContext(ctx, hostedZoneNameInput)
+	if err != nil {
+		return nil, nil, fmt.Errorf("hosted zone by name: %w", err)
+	}
+
+	hostedZoneID := getHostedZoneID(domain+".", list.HostedZones)
+	if hostedZoneID == nil {
+		return nil, nil, fmt.Errorf("hosted zone %q not found", domain)
+	}
+
+	hostedZoneInput := &route53.GetHostedZoneInput{
+		Id: hostedZoneID,
+	}
+
+	hostedZone, err := svc.GetHostedZoneWithContext(ctx, hostedZoneInput)
+	if err != nil {
+		return nil, nil, fmt.Errorf("hosted zone: %w", err)
+	}
+
+	return svc, hostedZone.HostedZone.Id, nil
+}
+
+// awsCredentials ensures that AWS credentials are present.
+func awsCredentials() error {
+	home, _ := os.UserHomeDir()
+	path := home + "/.aws/credentials"
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("env file stat: %w", err)
+	}
+
+	return nil
+}
+
+// getHostedZoneID returns pointer string of the Hosted Zone ID
+func getHostedZoneID(d string, l []*route53.HostedZone) *string {
+	for _, hz := range l {
+		if *hz.Name == d {
+			return hz.Id
+		}
+	}
+	return nil
+}
+
+// checkChange waits until the change in record is in sync, or the context is done.
+func checkChange(ctx context.Context, svc *route53.Route53, id *route53.GetChangeInput) error {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		change, err := svc.GetChangeWithContext(ctx, id)
+		if err != nil {
+			return fmt.Errorf("change status: %w", err)
+		}
+
+		if *change.ChangeInfo.Status == "INSYNC" {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("check change status: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package record
+
+import "testing"
+
+func TestSameRoute53Name(t *testing.T) {
+	tests := []struct {
+		setName string
+		name    string
+		want    bool
+	}{
+		{setName: "www.example.com.", name: "www.example.com", want: true},
+		{setName: "www.example.com.", name: "WWW.Example.com.", want: true},
+		{setName: `\052.example.com.`, name: "*.example.com", want: true},
+		{setName: `\052.example.com.`, name: "*.Example.com", want: true},
+		{setName: "api.example.com.", name: "www.example.com", want: false},
+		{setName: "www.example.com.", name: "www.example.co", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := sameRoute53Name(tt.setName, tt.name); got != tt.want {
+			t.Errorf("sameRoute53Name(%q, %q) = %v, want %v", tt.setName, tt.name, got, tt.want)
+		}
+	}
+}
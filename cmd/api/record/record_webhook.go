@@ -0,0 +1,124 @@
+package record
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// webhookTokenEnv is the environment variable read for the webhook token if no token file is provided.
+const webhookTokenEnv = "RCSTATE_DNS_WEBHOOK_TOKEN"
+
+// WebhookRecords manages DNS records with a JSON HTTP API of an in-house DNS service.
+// It implements the Provider interface.
+//
+// The API manages record sets by name and type at the endpoint:
+//
+//	GET    <endpoint>?name=<name>&type=<type>    returns the record set, or status 404 if there is none
+//	PUT    <endpoint>                            creates or replaces the record set of the request body
+//	DELETE <endpoint>?name=<name>&type=<type>    deletes the record set, status 404 is accepted
+//
+// Records are encoded as webhookRecord, and requests carry the token as bearer token if provided.
+type WebhookRecords struct {
+	Endpoint string
+	Token    string
+}
+
+// webhookRecord is a DNS record set of the webhook API.
+type webhookRecord struct {
+	Domain string   `json:"domain"`
+	Name   string   `json:"name"`
+	TTL    int64    `json:"ttl"`
+	Type   string   `json:"type"`
+	Values []string `json:"values"`
+}
+
+// NewWebhookRecords returns a WebhookRecords struct with provided API endpoint and token.
+func NewWebhookRecords(endpoint string, token string) *WebhookRecords {
+	return &WebhookRecords{
+		Endpoint: endpoint,
+		Token:    token,
+	}
+}
+
+// Upsert creates or replaces the record set with the same name and type with the DNS record.
+func (w *WebhookRecords) Upsert(ctx context.Context, r *Record) error {
+	body := webhookRecord{
+		Domain: strings.TrimSuffix(r.Domain, "."),
+		Name:   strings.TrimSuffix(r.Zone, "."),
+		TTL:    r.ttl(),
+		Type:   r.Type,
+		Values: r.IP,
+	}
+
+	status, err := doJSON(ctx, http.MethodPut, w.Endpoint, w.Token, body, nil)
+	if err != nil {
+		return err
+	}
+
+	if status >= 300 {
+		return fmt.Errorf("upsert record %s %s: webhook status %d", r.Zone, r.Type, status)
+	}
+
+	return nil
+}
+
+// Delete deletes the record set with the same name and type as the DNS record.
+func (w *WebhookRecords) Delete(ctx context.Context, r *Record) error {
+	status, err := doJSON(ctx, http.MethodDelete, w.url(r), w.Token, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if status >= 300 && status != http.StatusNotFound {
+		return fmt.Errorf("delete record %s %s: webhook status %d", r.Zone, r.Type, status)
+	}
+
+	return nil
+}
+
+// Lookup returns the record set with the same name and type as the DNS record, or nil if there is none.
+func (w *WebhookRecords) Lookup(ctx context.Context, r *Record) (*Record, error) {
+	var found webhookRecord
+
+	status, err := doJSON(ctx, http.MethodGet, w.url(r), w.Token, nil, &found)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if status >= 300 {
+		return nil, fmt.Errorf("lookup record %s %s: webhook status %d", r.Zone, r.Type, status)
+	}
+
+	if len(found.Values) == 0 {
+		return nil, nil
+	}
+
+	return &Record{
+		Domain: r.Domain,
+		IP:     found.Values,
+		TTL:    found.TTL,
+		Type:   r.Type,
+		Zone:   r.Zone,
+	}, nil
+}
+
+// url returns the endpoint with the name and type of the record as query parameters.
+func (w *WebhookRecords) url(r *Record) string {
+	query := url.Values{}
+	query.Set("name", strings.TrimSuffix(r.Zone, "."))
+	query.Set("type", r.Type)
+
+	sep := "?"
+	if strings.Contains(w.Endpoint, "?") {
+		sep = "&"
+	}
+
+	return w.Endpoint + sep + query.Encode()
+}
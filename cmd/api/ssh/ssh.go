@@ -2,7 +2,10 @@ package ssh
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"net"
 	"os"
 
 	"golang.org/x/crypto/ssh"
@@ -11,11 +14,12 @@ import (
 
 // SSH stores required SSH configuration.
 type SSH struct {
-	conf *ssh.ClientConfig
-	Host string
-	Key  string
-	Port string
-	User string
+	conf   *ssh.ClientConfig
+	Host   string
+	Key    string
+	Output io.Writer
+	Port   string
+	User   string
 }
 
 // NewSSH return a SSH struct.
@@ -38,7 +42,12 @@ func NewSSH(host string, port string, user string, keyPath string) (*SSH, error)
 		return nil, fmt.Errorf("parse key: %w", err)
 	}
 
-	hostKeyCallback, err := knownhosts.New("/Users/" + user + "/.ssh/known_hosts")
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("user home dir: %w", err)
+	}
+
+	hostKeyCallback, err := knownhosts.New(home + "/.ssh/known_hosts")
 	if err != nil {
 		return nil, fmt.Errorf("host key callback: %w", err)
 	}
@@ -53,11 +62,12 @@ func NewSSH(host string, port string, user string, keyPath string) (*SSH, error)
 	}
 
 	return &SSH{
-		conf: conf,
-		Host: host,
-		Key:  keyPath,
-		Port: port,
-		User: user,
+		conf:   conf,
+		Host:   host,
+		Key:    keyPath,
+		Output: os.Stdout,
+		Port:   port,
+		User:   user,
 	}, nil
 }
 
@@ -79,19 +89,40 @@ func checkSSHArgs(host string, port string, user string, keyPath string) error {
 	return nil
 }
 
-// CMD executes shell commands over SSH connection.
-func (s *SSH) CMD(cmd string) error {
-	dest := fmt.Sprintf("%s:%s", s.Host, s.Port)
+// CMD executes shell commands over SSH connection, and writes their output to the output writer.
+// The connection is closed, and the command is interrupted, when the context is done.
+func (s *SSH) CMD(ctx context.Context, cmd string) error {
+	dest := net.JoinHostPort(s.Host, s.Port)
 
-	conn, err := ssh.Dial("tcp", dest, s.conf)
+	var d net.Dialer
+	netConn, err := d.DialContext(ctx, "tcp", dest)
 	if err != nil {
 		return fmt.Errorf("ssh dial: %w", err)
 	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			netConn.Close()
+		case <-done:
+		}
+	}()
+
+	c, chans, reqs, err := ssh.NewClientConn(netConn, dest, s.conf)
+	if err != nil {
+		netConn.Close()
+		return fmt.Errorf("ssh dial: %w", contextError(ctx, err))
+	}
+
+	conn := ssh.NewClient(c, chans, reqs)
 	defer conn.Close()
 
 	session, err := conn.NewSession()
 	if err != nil {
-		return fmt.Errorf("new session: %w", err)
+		return fmt.Errorf("new session: %w", contextError(ctx, err))
 	}
 	defer session.Close()
 
@@ -108,16 +139,25 @@ func (s *SSH) CMD(cmd string) error {
 	if err := session.Run(cmd); err != nil {
 		scannerErr := bufio.NewScanner(stdErr)
 		for scannerErr.Scan() {
-			fmt.Println(scannerErr.Text())
+			fmt.Fprintln(s.Output, scannerErr.Text())
 		}
 
-		return fmt.Errorf("run cmd: %w", err)
+		return fmt.Errorf("run cmd: %w", contextError(ctx, err))
 	}
 
 	scannerOut := bufio.NewScanner(stdOut)
 	for scannerOut.Scan() {
-		fmt.Println(scannerOut.Text())
+		fmt.Fprintln(s.Output, scannerOut.Text())
 	}
 
 	return nil
 }
+
+// contextError returns the error of the context if it is done, or the error otherwise.
+func contextError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
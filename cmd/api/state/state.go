@@ -0,0 +1,126 @@
+// Package state implements a local store for values remembered between runs of rcstate.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// mu serializes access to the state file.
+var mu sync.Mutex
+
+// path returns the path to the state file.
+// The path can be set with environment variable RCSTATE_STATE_FILE.
+func path() (string, error) {
+	if p := os.Getenv("RCSTATE_STATE_FILE"); p != "" {
+		return p, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("user home dir: %w", err)
+	}
+
+	return filepath.Join(home, ".rcstate", "state.json"), nil
+}
+
+// load returns the values stored in the state file.
+func load() (map[string]string, error) {
+	values := map[string]string{}
+
+	p, err := path()
+	if err != nil {
+		return values, err
+	}
+
+	data, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return values, fmt.Errorf("read state file %q: %w", p, err)
+	}
+
+	if err := json.Unmarshal(data, &values); err != nil {
+		return values, fmt.Errorf("unmarshal state file %q: %w", p, err)
+	}
+
+	return values, nil
+}
+
+// save writes the values to the state file.
+func save(values map[string]string) error {
+	p, err := path()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return fmt.Errorf("create state dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal state: %w", err)
+	}
+
+	if err := os.WriteFile(p, data, 0o600); err != nil {
+		return fmt.Errorf("write state file %q: %w", p, err)
+	}
+
+	return nil
+}
+
+// Get returns the value stored for the key, and whether the key is present.
+func Get(key string) (string, bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	values, err := load()
+	if err != nil {
+		return "", false, err
+	}
+
+	v, ok := values[key]
+
+	return v, ok, nil
+}
+
+// Set stores the value for the key.
+func Set(key string, value string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	values, err := load()
+	if err != nil {
+		return err
+	}
+
+	values[key] = value
+
+	return save(values)
+}
+
+// Delete removes the key from the store.
+func Delete(key string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	values, err := load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := values[key]; !ok {
+		return nil
+	}
+
+	delete(values, key)
+
+	return save(values)
+}
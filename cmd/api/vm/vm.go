@@ -1,43 +1,64 @@
 package vm
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/marintailor/rcstate/cmd/api/gce"
+	"github.com/marintailor/rcstate/cmd/api/compute"
+	"github.com/marintailor/rcstate/cmd/api/flagutil"
+	"github.com/marintailor/rcstate/cmd/api/provider"
 	"github.com/marintailor/rcstate/cmd/api/record"
 	"github.com/marintailor/rcstate/cmd/api/ssh"
 )
 
 // VirtualMachine holds configuration and methods to manage virtual machine instances.
 type VirtualMachine struct {
-	Instances gce.Instances
-	Project   string
-	Zone      string
+	AllZones bool
+	Projects []string
+	Provider provider.Provider
+	Project  string
+	Region   string
+	Zone     string
+	options  provider.Options
 }
 
 // Config stores options from parsed flags.
 type Config struct {
-	DNS        DNS
-	Dry        bool
-	ExternalIP bool
-	Format     string
-	Host       string
-	Ip         string
-	IpList     []string
-	Name       string
-	Project    string
-	Script     VMScript
-	Zone       string
+	AllZones    bool
+	DNS         DNS
+	Dry         bool
+	Endpoint    string
+	ExternalIP  bool
+	Format      string
+	Host        string
+	Ip          string
+	IpList      []string
+	MachineType string
+	Name        string
+	Project     string
+	Projects    []string
+	Provider    string
+	Region      string
+	Script      VMScript
+	Timeout     time.Duration
+	Zone        string
 }
 
 // DNS stores DNS configuration.
 type DNS struct {
 	Domain     string
+	Endpoint   string
+	Provider   string
+	Proxied    bool
 	RecordName string
 	RecordType string
+	Server     string
+	TokenFile  string
+	TSIG       record.TSIG
 }
 
 // VMScript stores shell commands.
@@ -47,12 +68,30 @@ type VMScript struct {
 }
 
 // NewVirtualMachine returns a VirtualMachine struct.
-func NewVirtualMachine(project string, zone string) (*VirtualMachine, error) {
-	var vm VirtualMachine
+func NewVirtualMachine(o provider.Options) (*VirtualMachine, error) {
+	p, err := compute.NewProvider(o)
+	if err != nil {
+		return nil, fmt.Errorf("new provider: %w", err)
+	}
 
-	vm.Instances = *gce.NewInstances(project, zone)
+	return &VirtualMachine{
+		Provider: p,
+		Project:  o.Project,
+		Region:   o.Region,
+		Zone:     o.Zone,
+		options:  o,
+	}, nil
+}
 
-	return &vm, nil
+// ProviderOptions returns the options to select the compute provider.
+func (c *Config) ProviderOptions() provider.Options {
+	return provider.Options{
+		Endpoint: c.Endpoint,
+		Name:     c.Provider,
+		Project:  c.Project,
+		Region:   c.Region,
+		Zone:     c.Zone,
+	}
 }
 
 // GetConfig will get configuration from JSON.
@@ -64,11 +103,15 @@ func (c *Config) GetConfig(b []byte) error {
 func (c *Config) ParseFlags(args []string) error {
 	f := flag.NewFlagSet(args[0], flag.ExitOnError)
 
+	f.BoolVar(&c.AllZones, "all-zones", false, "List instances from all zones of the project(s)")
+
 	f.StringVar(&c.DNS.Domain, "domain", "", "Domain for DNS record")
 	f.StringVar(&c.DNS.Domain, "d", "", "Domain for DNS record")
 
 	f.BoolVar(&c.Dry, "dry", false, "Run the command without executing it")
 
+	f.StringVar(&c.Endpoint, "endpoint", "", "Custom API endpoint of the compute provider")
+
 	f.BoolVar(&c.ExternalIP, "external-ip", false, "Get external IP address for DNS record")
 
 	f.StringVar(&c.Format, "format", "", "Output format of API request")
@@ -79,16 +122,39 @@ func (c *Config) ParseFlags(args []string) error {
 
 	f.StringVar(&c.Ip, "ip", "", "IP addresses for DNS record")
 
+	f.StringVar(&c.MachineType, "machine-type", "", "Machine type applied before the instance is started")
+
 	f.StringVar(&c.Name, "name", "", "Virtual Machine instance name")
 	f.StringVar(&c.Name, "n", "", "Virtual Machine instance name")
 
-	f.StringVar(&c.Project, "project", "", "Google Cloud Project ID")
-	f.StringVar(&c.Project, "p", "", "Google Cloud Project ID")
+	f.Var((*flagutil.StringList)(&c.Projects), "project", "Google Cloud Project ID, can be repeated for vm list")
+	f.Var((*flagutil.StringList)(&c.Projects), "p", "Google Cloud Project ID, can be repeated for vm list")
+
+	f.StringVar(&c.Provider, "provider", "", "Compute provider name")
+
+	f.StringVar(&c.Region, "region", "", "AWS region name")
+	f.StringVar(&c.Region, "r", "", "AWS region name")
+
+	f.StringVar(&c.DNS.Endpoint, "dns-endpoint", "", "API endpoint of the DNS provider")
+
+	f.StringVar(&c.DNS.Provider, "dns-provider", "", "DNS provider of the record")
+
+	f.BoolVar(&c.DNS.Proxied, "dns-proxied", false, "Proxy the traffic of the Cloudflare DNS record")
 
 	f.StringVar(&c.DNS.RecordName, "dns-record-name", "", "DNS record name")
 
 	f.StringVar(&c.DNS.RecordType, "dns-record-type", "", "Create the DNS record")
 
+	f.StringVar(&c.DNS.Server, "dns-server", "", "DNS server that accepts dynamic updates")
+
+	f.StringVar(&c.DNS.TokenFile, "dns-token-file", "", "File with the API token of the DNS provider")
+
+	f.StringVar(&c.DNS.TSIG.Algorithm, "tsig-algorithm", "", "TSIG algorithm of the dynamic updates")
+
+	f.StringVar(&c.DNS.TSIG.Name, "tsig-key", "", "TSIG key name of the dynamic updates")
+
+	f.StringVar(&c.DNS.TSIG.SecretFile, "tsig-secret-file", "", "File with the TSIG secret of the dynamic updates")
+
 	f.StringVar(&c.Script.CMD, "script", "", "run shell command on remote host")
 	f.StringVar(&c.Script.CMD, "s", "", "run shell command on remote host")
 
@@ -98,6 +164,8 @@ func (c *Config) ParseFlags(args []string) error {
 
 	f.StringVar(&c.Script.SSH.User, "ssh-user", "", "SSH username")
 
+	f.DurationVar(&c.Timeout, "timeout", 10*time.Minute, "Maximum duration of the command")
+
 	f.StringVar(&c.Zone, "zone", "", "Google Cloud Zone name")
 	f.StringVar(&c.Zone, "z", "", "Google Cloud Zone name")
 
@@ -107,13 +175,26 @@ func (c *Config) ParseFlags(args []string) error {
 		return fmt.Errorf("parse flags for command %q: %w", args[0], err)
 	}
 
+	if len(c.Projects) > 0 {
+		c.Project = c.Projects[0]
+	}
+
 	return nil
 }
 
+// Context returns a context that is canceled after the timeout of the command.
+func (c *Config) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	if c.Timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+
+	return context.WithTimeout(parent, c.Timeout)
+}
+
 // Record will create a DNS record.
-func (c *Config) Record(dnsRecord string) {
+func (c *Config) Record(ctx context.Context, dnsRecord string) {
 	if c.ExternalIP {
-		externalIP, err := gce.GetInstanceExternalIP(c.Name, c.Project, c.Zone)
+		externalIP, err := c.externalIP(ctx)
 		if err != nil {
 			fmt.Printf("create record: get external IP address: %s", err)
 			return
@@ -131,19 +212,41 @@ func (c *Config) Record(dnsRecord string) {
 		c.IpList = append(c.IpList, ips...)
 	}
 
-	if record.CheckRecordIP(dnsRecord, c.IpList) {
+	dns, err := record.NewProvider(record.Options{
+		Name:      c.DNS.Provider,
+		Project:   c.Project,
+		Endpoint:  c.DNS.Endpoint,
+		Proxied:   c.DNS.Proxied,
+		Server:    c.DNS.Server,
+		TokenFile: c.DNS.TokenFile,
+		TSIG:      c.DNS.TSIG,
+	})
+	if err != nil {
+		fmt.Println("new record:", err)
 		return
 	}
 
-	if err := record.NewRecord(c.IpList, c.DNS.RecordType, dnsRecord, c.DNS.Domain).Route53(); err != nil {
+	rec := record.NewRecord(c.IpList, c.DNS.RecordType, dnsRecord, c.DNS.Domain)
+
+	status, err := record.Check(ctx, dns, rec)
+	if err != nil {
+		fmt.Println("check record:", err)
+		return
+	}
+
+	if status.UpToDate() {
+		return
+	}
+
+	if err := dns.Upsert(ctx, rec); err != nil {
 		fmt.Println("new record:", err)
 		return
 	}
 }
 
 // ExecuteScript will execute the shell commands.
-func (c *Config) ExecuteScript() {
-	host := c.getHost()
+func (c *Config) ExecuteScript(ctx context.Context) {
+	host := c.getHost(ctx)
 
 	script, err := ssh.NewSSH(host, c.Script.SSH.Port, c.Script.SSH.User, c.Script.SSH.Key)
 	if err != nil {
@@ -151,13 +254,13 @@ func (c *Config) ExecuteScript() {
 		return
 	}
 
-	if err := script.CMD(c.Script.CMD); err != nil {
+	if err := script.CMD(ctx, c.Script.CMD); err != nil {
 		fmt.Println("vm script cmd:", err)
 	}
 }
 
 // getHost return a valid host address.
-func (c *Config) getHost() string {
+func (c *Config) getHost(ctx context.Context) string {
 	var host string
 	if c.DNS.RecordName != "" && c.DNS.Domain != "" {
 		host = fmt.Sprintf("%s.%s", c.DNS.RecordName, c.DNS.Domain)
@@ -165,7 +268,7 @@ func (c *Config) getHost() string {
 
 	if host == "" && c.ExternalIP {
 		var err error
-		host, err = gce.GetInstanceExternalIP(c.Name, c.Project, c.Zone)
+		host, err = c.externalIP(ctx)
 		if err != nil {
 			fmt.Printf("create record: get external IP address: %s", err)
 		}
@@ -180,3 +283,13 @@ func (c *Config) getHost() string {
 
 	return host
 }
+
+// externalIP returns the external IP address of the instance from the selected provider.
+func (c *Config) externalIP(ctx context.Context) (string, error) {
+	p, err := compute.NewProvider(c.ProviderOptions())
+	if err != nil {
+		return "", fmt.Errorf("new provider: %w", err)
+	}
+
+	return p.ExternalIP(ctx, c.Name)
+}
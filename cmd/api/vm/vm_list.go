@@ -2,40 +2,86 @@ package vm
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"reflect"
 	"strings"
 
-	"github.com/marintailor/rcstate/cmd/api/gce"
+	"github.com/marintailor/rcstate/cmd/api/compute"
+	"github.com/marintailor/rcstate/cmd/api/provider"
 )
 
-// List returns a table formatted list of instances.
-func (vm *VirtualMachine) List() string {
-	if _, err := vm.Instances.GetInstancesList(); err != nil {
-		fmt.Println("list: get instances list:", err)
+// List returns a table formatted list of instances for each project.
+func (vm *VirtualMachine) List(ctx context.Context) string {
+	projects := vm.Projects
+	if len(projects) == 0 {
+		projects = []string{vm.Project}
 	}
 
-	columnNames := []string{"Name", "Status", "Internal", "External", "Type", "Preemptible"}
-	columnWidth := getColumnsWidth(columnNames, vm.Instances.List)
-
 	var out bytes.Buffer
 
-	tableHeader(&out, columnWidth, vm.Project, vm.Zone)
-	tableColumns(&out, columnNames, columnWidth)
-	tableRows(&out, vm.Instances.List, columnNames, columnWidth)
+	for _, project := range projects {
+		list, err := vm.listProject(ctx, project)
+		if err != nil {
+			fmt.Println("list: get instances list:", err)
+		}
+
+		columnNames := []string{"Name", "Zone", "Status", "Internal", "External", "Type", "Preemptible"}
+		columnWidth := getColumnsWidth(columnNames, list)
+
+		zone := vm.Zone
+		if vm.AllZones {
+			zone = "all"
+		}
+
+		location := fmt.Sprintf("PROJECT: %s    ZONE: %s", project, zone)
+		if vm.Region != "" {
+			location = fmt.Sprintf("REGION: %s", vm.Region)
+		}
+
+		tableHeader(&out, columnWidth, location)
+		tableColumns(&out, columnNames, columnWidth)
+		tableRows(&out, list, columnNames, columnWidth)
+	}
 
 	return out.String()
 }
 
+// listProject returns the instances of a project from the zone, or from all zones.
+func (vm *VirtualMachine) listProject(ctx context.Context, project string) ([]provider.Instance, error) {
+	p := vm.Provider
+	if project != vm.Project {
+		o := vm.options
+		o.Project = project
+
+		var err error
+		p, err = compute.NewProvider(o)
+		if err != nil {
+			return nil, fmt.Errorf("new provider: %w", err)
+		}
+	}
+
+	if !vm.AllZones {
+		return p.GetList(ctx)
+	}
+
+	a, ok := p.(provider.AggregatedLister)
+	if !ok {
+		return nil, fmt.Errorf("listing all zones is not supported by the provider")
+	}
+
+	return a.GetAggregatedList(ctx)
+}
+
 // tableHeader writes to a writer the header for the table.
-func tableHeader(w io.Writer, columnWidths []int, project string, zone string) {
+func tableHeader(w io.Writer, columnWidths []int, location string) {
 	line := ""
 	for _, w := range columnWidths {
 		line = line + strings.Repeat("=", w+2)
 	}
 
-	fmt.Fprintf(w, "\n%s\nPROJECT: %s    ZONE: %s\n%s\n\n", line, project, zone, line)
+	fmt.Fprintf(w, "\n%s\n%s\n%s\n\n", line, location, line)
 }
 
 // tableColumns writes to a writer the column names of the table.
@@ -52,7 +98,7 @@ func tableColumns(w io.Writer, columnNames []string, columnWidths []int) {
 }
 
 // tableRows writes to a writer a row of details for each instance.
-func tableRows(w io.Writer, list []gce.Instance, columnNames []string, columnWidths []int) {
+func tableRows(w io.Writer, list []provider.Instance, columnNames []string, columnWidths []int) {
 	for _, inst := range list {
 		row := ""
 		v := reflect.ValueOf(inst)
@@ -72,7 +118,7 @@ func tableRows(w io.Writer, list []gce.Instance, columnNames []string, columnWid
 }
 
 // getColumnsWidth returns a slice of widths for each table column based on values of instance's details.
-func getColumnsWidth(columnNames []string, list []gce.Instance) []int {
+func getColumnsWidth(columnNames []string, list []provider.Instance) []int {
 	columnWidths := make([]int, len(columnNames))
 
 	// Initial column width based on column names
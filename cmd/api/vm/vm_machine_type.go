@@ -0,0 +1,18 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/marintailor/rcstate/cmd/api/provider"
+)
+
+// SetMachineType will change the machine type of a stopped virtual machine.
+func (vm *VirtualMachine) SetMachineType(ctx context.Context, name string, machineType string) error {
+	mt, ok := vm.Provider.(provider.MachineTyper)
+	if !ok {
+		return fmt.Errorf("changing machine type is not supported by the provider")
+	}
+
+	return mt.SetMachineType(ctx, name, machineType)
+}
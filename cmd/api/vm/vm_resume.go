@@ -0,0 +1,18 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/marintailor/rcstate/cmd/api/provider"
+)
+
+// Resume will resume a suspended virtual machine.
+func (vm *VirtualMachine) Resume(ctx context.Context, name string) error {
+	s, ok := vm.Provider.(provider.Suspender)
+	if !ok {
+		return fmt.Errorf("resume is not supported by the provider")
+	}
+
+	return s.Resume(ctx, name)
+}
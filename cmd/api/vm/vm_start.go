@@ -1,6 +1,8 @@
 package vm
 
+import "context"
+
 // Start will stop a virtual machine.
-func (vm *VirtualMachine) Start(name string) error {
-	return vm.Instances.Start(name)
+func (vm *VirtualMachine) Start(ctx context.Context, name string) error {
+	return vm.Provider.Start(ctx, name)
 }
@@ -1,6 +1,8 @@
 package vm
 
+import "context"
+
 // Status returns the status of the virtual machine.
-func (vm *VirtualMachine) Status(name string) (string, error) {
-	return vm.Instances.Status(name)
+func (vm *VirtualMachine) Status(ctx context.Context, name string) (string, error) {
+	return vm.Provider.Status(ctx, name)
 }
@@ -1,6 +1,8 @@
 package vm
 
+import "context"
+
 // Stop will stop a virtual machine.
-func (vm *VirtualMachine) Stop(name string) error {
-	return vm.Instances.Stop(name)
+func (vm *VirtualMachine) Stop(ctx context.Context, name string) error {
+	return vm.Provider.Stop(ctx, name)
 }
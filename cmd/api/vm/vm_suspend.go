@@ -0,0 +1,18 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/marintailor/rcstate/cmd/api/provider"
+)
+
+// Suspend will suspend a virtual machine.
+func (vm *VirtualMachine) Suspend(ctx context.Context, name string) error {
+	s, ok := vm.Provider.(provider.Suspender)
+	if !ok {
+		return fmt.Errorf("suspend is not supported by the provider")
+	}
+
+	return s.Suspend(ctx, name)
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"fmt"
+)
+
+// dnsRun runs the logic for the dns command
+func dnsRun(args []string) int {
+	if len(args) == 0 || args[0] == "help" {
+		dnsHelp()
+		return 0
+	}
+
+	commands := map[string]func([]string) int{
+		"check": func(a []string) int { return dnsCheck(a) },
+	}
+
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Println("No such command: dns", args[0])
+		fmt.Printf("\nFor usage information type:\n\n    rcstate dns help\n\n")
+		return 1
+	}
+
+	return command(args)
+}
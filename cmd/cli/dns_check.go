@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/marintailor/rcstate/cmd/api/env"
+)
+
+// dnsCheck reports the DNS records of the environment(s) that are out of date.
+// It returns 1 if a record is out of date, or could not be checked.
+func dnsCheck(args []string) int {
+	cfg := env.Config{}
+
+	if err := cfg.ParseFlags(args); err != nil {
+		fmt.Println("get config:", err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	if err := cfg.ParseEnvironmentFile(); err != nil {
+		fmt.Println("parse env file:", err)
+		return 1
+	}
+
+	data, err := cfg.GetData()
+	if err != nil {
+		fmt.Println("get config data:", err)
+	}
+
+	e, err := env.NewEnvironments(string(data))
+	if err != nil {
+		fmt.Println("new environment:", err)
+		return 1
+	}
+
+	switch {
+	case cfg.Name != "":
+		environment, err := e.GetEnvironment(cfg.Name, cfg.Label)
+		if err != nil {
+			fmt.Println("dns check: get env:", err)
+			return 1
+		}
+
+		if !checkRecords(ctx, environment) {
+			return 1
+		}
+	case cfg.All:
+		var count int
+		ok := true
+
+		for _, environment := range e.Envs {
+			if !environment.CheckLabel(cfg.Label) {
+				continue
+			}
+
+			ok = checkRecords(ctx, environment) && ok
+			count++
+		}
+
+		if len(e.Envs) > 0 && count == 0 {
+			fmt.Printf("no environment is labeled with %q\n", cfg.Label)
+		}
+
+		if !ok {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// checkRecords prints the state of the DNS records of the environment,
+// and returns whether all records are up to date.
+func checkRecords(ctx context.Context, environment env.Environment) bool {
+	fmt.Printf("\n%s\nENVIRONMENT: %s\nLABEL: %s\n%s\n", strings.Repeat("=", 40), environment.Name, environment.Label, strings.Repeat("=", 40))
+
+	checks := environment.CheckRecords(ctx)
+
+	if len(checks) == 0 {
+		fmt.Printf("\nno DNS records are declared\n\n")
+		return true
+	}
+
+	pw := 16
+	for _, c := range checks {
+		if name := checkName(c); len(name) >= pw {
+			pw = len(name) + 2
+		}
+	}
+
+	fmt.Printf("\nDNS RECORDS\n")
+
+	ok := true
+
+	for i, c := range checks {
+		name := checkName(c)
+		padding := strings.Repeat(" ", pw-len(name))
+
+		var state string
+
+		switch {
+		case c.Err != nil:
+			state = "ERROR: " + c.Err.Error()
+			ok = false
+		case c.Skipped != "":
+			state = "SKIPPED: " + c.Skipped
+		case c.Status.UpToDate():
+			state = "UP TO DATE"
+		default:
+			state = "OUT OF DATE: " + strings.Join(c.Status.Diff, "; ")
+			ok = false
+		}
+
+		fmt.Printf("%d. %s%s%s\n", i+1, name, padding, state)
+	}
+	fmt.Println()
+
+	return ok
+}
+
+// checkName returns the name of a record check, the record name and type if known, or the instance otherwise.
+func checkName(c env.RecordCheck) string {
+	node := c.Group
+	if c.Instance != "" {
+		node += "/" + c.Instance
+	}
+
+	if c.Record == nil {
+		return node
+	}
+
+	return fmt.Sprintf("%s %s %s", node, c.Record.Zone, c.Record.Type)
+}
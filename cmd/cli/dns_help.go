@@ -0,0 +1,50 @@
+package cli
+
+import "fmt"
+
+// dnsHelp will print the usage information for dns command
+func dnsHelp() {
+	text := `
+dns command usage:
+  rcstate dns <command> [option...]
+
+Commands:
+  check   report the DNS records of environment(s) that are out of date
+  help    show usage information
+
+Options:
+  -a, --all        check all environments
+                   option is ignored when option "env" is provided
+
+  -e, --env-file   environment file
+
+  --env            environment name, same as option "name"
+
+  -l, --label      environment label
+
+  -n, --name       environment name
+
+  --timeout        timeout of an operation kind in format kind=duration
+                   the "dns" timeout bounds the check of each record
+
+Records are compared with the current record sets of their DNS providers, by values, TTL, and type.
+A running instance expects its record, and a stopped instance expects the result of its "on_down" policy.
+The command exits with status 1 if a record is out of date, or can not be checked.
+
+Examples:
+  Check the DNS records of an environment:
+
+    rcstate dns check \
+      --env <env_name> \
+      --env-file <env_file>
+
+
+  Check the DNS records of all environments with specific label(s):
+
+    rcstate dns check \
+      --all \
+      --label <label> \
+      --env-file <env_file>
+`
+	fmt.Println(text)
+}
@@ -12,9 +12,11 @@ func envRun(args []string) int {
 	}
 
 	commands := map[string]func([]string) int{
-		"down": func(a []string) int { return envDown(a) },
-		"show": func(a []string) int { return envShow(a) },
-		"up":   func(a []string) int { return envUp(a) },
+		"down":   func(a []string) int { return envDown(a) },
+		"import": func(a []string) int { return envImport(a) },
+		"show":   func(a []string) int { return envShow(a) },
+		"up":     func(a []string) int { return envUp(a) },
+		"watch":  func(a []string) int { return envWatch(a) },
 	}
 
 	command, ok := commands[args[0]]
@@ -33,6 +33,9 @@ func envDown(args []string) int {
 
 // downLocal will stop resources by executing the logic locally.
 func downLocal(cfg *env.Config) int {
+	ctx, stop := signalContext()
+	defer stop()
+
 	data, err := cfg.GetData()
 	if err != nil {
 		fmt.Println("get config data:", err)
@@ -53,7 +56,7 @@ func downLocal(cfg *env.Config) int {
 		}
 
 		fmt.Printf("\n%s\nENVIRONMENT: %s\nLABEL: %s\n%s\n", strings.Repeat("=", 40), env.Name, env.Label, strings.Repeat("=", 40))
-		env.State("down")
+		env.State(ctx, "down")
 
 		return 0
 	case cfg.All:
@@ -65,7 +68,7 @@ func downLocal(cfg *env.Config) int {
 			}
 
 			fmt.Printf("\n%s\nENVIRONMENT: %s\nLABEL: %s\n%s\n", strings.Repeat("=", 40), env.Name, env.Label, strings.Repeat("=", 40))
-			env.State("down")
+			env.State(ctx, "down")
 			count++
 		}
 
@@ -11,8 +11,10 @@ env command usage:
 Commands:
   down    stop all resources in environment(s)
   help    show usage information
+  import  generate an environment file from existing instances
   show    show environment(a)
   up      start all resources in environment(s)
+  watch   restart preempted instances of active environment(s)
 
 Options:
   -a, --all        show all environments
@@ -25,10 +27,32 @@ Options:
 
   -e, --env-file   environment file
 
+  --filter         select instances by label in format label=value for command "import"
+                   can be repeated
+
   -h, --host       address of the remote host where the command will be executed
 
+  --interval       interval between checks for command "watch"
+                   default: 1m
+
+  --max-retries    maximum consecutive failed restarts of an instance for command "watch"
+                   default: 3
+
   -n, --name       environment name
 
+  --parallel       maximum number of instances, and of containers, of a group managed concurrently
+                   applies to groups without "parallel" or with "parallel: true"
+
+  -p, --project    Google Cloud Project ID for command "import"
+                   can be repeated
+
+  --timeout        timeout of an operation kind in format kind=duration
+                   kinds: dns, script, snapshot, start, stop
+                   overrides the timeouts of the environment file, can be repeated
+
+  -z, --zone       Google Cloud Zone name for command "import"
+                   instances from all zones are imported if omitted
+
 Examples:
   Show all environments:
 
@@ -67,6 +91,23 @@ Examples:
      --help <host_addr>
 
 
+  Generate an environment file from instances labeled with "env=dev":
+
+    rcstate env import \
+      --name <env_name> \
+      --project <project_id> \
+      --zone <zone_name> \
+      --filter env=dev > <env_file>
+
+
+  Restart preempted instances of an environment that was brought up:
+
+    rcstate env watch \
+      --name <env_name> \
+      --env-file <env_file> \
+      --interval 30s
+
+
   Print the API request data in JSON format without executing the command:
 
     rcstate env show \
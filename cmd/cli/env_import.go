@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/marintailor/rcstate/cmd/api/env"
+)
+
+// envImport prints an environment file generated from existing instances.
+func envImport(args []string) int {
+	cfg := env.Config{}
+
+	if err := cfg.ParseFlags(args); err != nil {
+		fmt.Println("get config:", err)
+	}
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	out, err := cfg.Import(ctx)
+	if err != nil {
+		fmt.Println("import:", err)
+		return 1
+	}
+
+	fmt.Print(out)
+
+	return 0
+}
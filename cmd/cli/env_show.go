@@ -1,13 +1,18 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 
 	client "github.com/marintailor/rcstate/client/env"
+	"github.com/marintailor/rcstate/cmd/api/cloudsql"
+	"github.com/marintailor/rcstate/cmd/api/container"
 	"github.com/marintailor/rcstate/cmd/api/env"
 	"github.com/marintailor/rcstate/cmd/api/gce"
+	"github.com/marintailor/rcstate/cmd/api/gke"
+	"github.com/marintailor/rcstate/cmd/api/provider"
 )
 
 // envShow returns the information about the environment(s).
@@ -27,6 +32,9 @@ func envShow(args []string) int {
 
 // showLocal returns the information about the environment(s) by executing the logic locally.
 func showLocal(c *env.Config) int {
+	ctx, stop := signalContext()
+	defer stop()
+
 	if err := c.ParseEnvironmentFile(); err != nil {
 		fmt.Println("parse env file:", err)
 		return 1
@@ -51,7 +59,7 @@ func showLocal(c *env.Config) int {
 			return 1
 		}
 
-		showEnvironment(environment)
+		showEnvironment(ctx, environment)
 
 		return 0
 	case c.All:
@@ -62,7 +70,7 @@ func showLocal(c *env.Config) int {
 				continue
 			}
 
-			showEnvironment(environment)
+			showEnvironment(ctx, environment)
 
 			count++
 		}
@@ -169,34 +177,31 @@ func remoteCheckLabel(c *env.Config) (bool, error) {
 }
 
 // showEnvironment will show all resources in specific environment.
-func showEnvironment(env env.Environment) {
+func showEnvironment(ctx context.Context, env env.Environment) {
 	fmt.Printf("\n%s\nENVIRONMENT: %s\nLABEL: %s\n%s\n", strings.Repeat("=", 40), env.Name, env.Label, strings.Repeat("=", 40))
 	for i, g := range env.Group {
 		if i > 0 {
 			fmt.Println(strings.Repeat("-", 40))
 		}
 
-		fmt.Printf("\nGROUP: %s\nPROJECT: %s\nZONE: %s\n\n", g.Name, g.Project, g.Zone)
+		groupHeader(g.Name, g.Project, g.Zone, g.Region)
 		fmt.Printf("VIRTUAL MACHINES\n")
 
-		pw := padWidth(g.Resource.VM.Instance)
+		list, snapshots := g.GetDetailsVM(ctx)
 
-		list, err := gce.NewInstances(g.Project, g.Zone).GetList()
-		if err != nil {
-			fmt.Printf("new instances: %v", err)
-		}
+		pw := padWidth(list)
 
-		for j, instance := range g.Resource.VM.Instance {
-			var status string
-			for _, inst := range list {
-				if inst.Name == instance.Name {
-					status = inst.Status
-				}
-			}
+		for j, instance := range list {
 			padding := strings.Repeat(" ", pw-len(instance.Name))
-			fmt.Printf("%d. %s%sStatus: %s\n", j+1, instance.Name, padding, status)
+			fmt.Printf("%d. %s%sStatus: %s\n", j+1, instance.Name, padding, instance.Status)
 		}
 		fmt.Println()
+
+		showSnapshots(snapshots)
+		showMIG(g.GetDetailsMIG(ctx))
+		showGKE(g.GetDetailsGKE(ctx))
+		showSQL(g.GetDetailsSQL(ctx))
+		showContainers(g.GetDetailsContainer(ctx))
 	}
 }
 
@@ -208,44 +213,153 @@ func showEnvironmentRemote(env env.ShowEnvironment) {
 			fmt.Println(strings.Repeat("-", 40))
 		}
 
-		fmt.Printf("\nGROUP: %s\nPROJECT: %s\nZONE: %s\n\n", g.Name, g.Project, g.Zone)
+		groupHeader(g.Name, g.Project, g.Zone, g.Region)
 		fmt.Printf("VIRTUAL MACHINES\n")
 
-		pw := padWidthRemote(g.Resource.VM)
-
-		list, err := gce.NewInstances(g.Project, g.Zone).GetList()
-		if err != nil {
-			fmt.Printf("new instances: %v", err)
-		}
+		pw := padWidth(g.Resource.VM)
 
 		for j, instance := range g.Resource.VM {
-			var status string
-			for _, inst := range list {
-				if inst.Name == instance.Name {
-					status = inst.Status
-				}
-			}
 			padding := strings.Repeat(" ", pw-len(instance.Name))
-			fmt.Printf("%d. %s%sStatus: %s\n", j+1, instance.Name, padding, status)
+			fmt.Printf("%d. %s%sStatus: %s\n", j+1, instance.Name, padding, instance.Status)
 		}
 		fmt.Println()
+
+		showSnapshots(g.Resource.Snapshot)
+		showMIG(g.Resource.MIG)
+		showGKE(g.Resource.GKE)
+		showSQL(g.Resource.SQL)
+		showContainers(g.Resource.Container)
 	}
 }
 
-// padWidth returns width of the pad.
-func padWidth(instances []env.Instance) int {
+// showSnapshots will show the latest snapshot of instances in a group.
+func showSnapshots(list []provider.Snapshot) {
+	if len(list) == 0 {
+		return
+	}
+
+	fmt.Printf("LATEST SNAPSHOTS\n")
+
 	pw := 16
-	for _, instance := range instances {
-		if len(instance.Name) >= pw {
-			pw = len(instance.Name) + 2
+	for _, s := range list {
+		if len(s.Instance) >= pw {
+			pw = len(s.Instance) + 2
 		}
 	}
 
-	return pw
+	for i, s := range list {
+		padding := strings.Repeat(" ", pw-len(s.Instance))
+		fmt.Printf("%d. %s%sSnapshot: %s    Created: %s\n", i+1, s.Instance, padding, s.Name, s.Created)
+	}
+	fmt.Println()
+}
+
+// showMIG will show the current and target size of managed instance groups in a group.
+func showMIG(list []gce.InstanceGroup) {
+	if len(list) == 0 {
+		return
+	}
+
+	fmt.Printf("MANAGED INSTANCE GROUPS\n")
+
+	pw := 16
+	for _, m := range list {
+		if len(m.Name) >= pw {
+			pw = len(m.Name) + 2
+		}
+	}
+
+	for i, m := range list {
+		padding := strings.Repeat(" ", pw-len(m.Name))
+		fmt.Printf("%d. %s%sSize: %d/%d\n", i+1, m.Name, padding, m.CurrentSize, m.TargetSize)
+	}
+	fmt.Println()
+}
+
+// showGKE will show the size of GKE node pools in a group.
+func showGKE(list []gke.NodePool) {
+	if len(list) == 0 {
+		return
+	}
+
+	fmt.Printf("GKE NODE POOLS\n")
+
+	pw := 16
+	for _, np := range list {
+		name := np.Cluster + "/" + np.Name
+		if len(name) >= pw {
+			pw = len(name) + 2
+		}
+	}
+
+	for i, np := range list {
+		name := np.Cluster + "/" + np.Name
+		padding := strings.Repeat(" ", pw-len(name))
+		autoscaling := "off"
+		if np.Autoscaling {
+			autoscaling = fmt.Sprintf("%d-%d", np.MinNodes, np.MaxNodes)
+		}
+		fmt.Printf("%d. %s%sNodes: %d    Autoscaling: %s\n", i+1, name, padding, np.NodeCount, autoscaling)
+	}
+	fmt.Println()
+}
+
+// showSQL will show the state of Cloud SQL instances in a group.
+func showSQL(list []cloudsql.Instance) {
+	if len(list) == 0 {
+		return
+	}
+
+	fmt.Printf("CLOUD SQL INSTANCES\n")
+
+	pw := 16
+	for _, inst := range list {
+		if len(inst.Name) >= pw {
+			pw = len(inst.Name) + 2
+		}
+	}
+
+	for i, inst := range list {
+		padding := strings.Repeat(" ", pw-len(inst.Name))
+		fmt.Printf("%d. %s%sStatus: %s    Activation policy: %s\n", i+1, inst.Name, padding, inst.State, inst.ActivationPolicy)
+	}
+	fmt.Println()
+}
+
+// showContainers will show the status of containers in a group.
+func showContainers(list []container.Container) {
+	if len(list) == 0 {
+		return
+	}
+
+	fmt.Printf("CONTAINERS\n")
+
+	pw := 16
+	for _, c := range list {
+		if len(c.Name) >= pw {
+			pw = len(c.Name) + 2
+		}
+	}
+
+	for i, c := range list {
+		padding := strings.Repeat(" ", pw-len(c.Name))
+		fmt.Printf("%d. %s%sStatus: %s\n", i+1, c.Name, padding, c.Status)
+	}
+	fmt.Println()
+}
+
+// groupHeader prints the name and location of a group.
+func groupHeader(name string, project string, zone string, region string) {
+	if region != "" {
+		fmt.Printf("\nGROUP: %s\nREGION: %s\n\n", name, region)
+		return
+	}
+
+	fmt.Printf("\nGROUP: %s\nPROJECT: %s\nZONE: %s\n\n", name, project, zone)
 }
 
 // padWidth returns width of the pad.
-func padWidthRemote(instances []gce.Instance) int {
+func padWidth(instances []provider.Instance) int {
 	pw := 16
 	for _, instance := range instances {
 		if len(instance.Name) >= pw {
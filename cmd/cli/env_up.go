@@ -28,6 +28,9 @@ func envUp(args []string) int {
 
 // upLocal will stop resources by executing the logic locally.
 func upLocal(c *env.Config) int {
+	ctx, stop := signalContext()
+	defer stop()
+
 	if err := c.ParseEnvironmentFile(); err != nil {
 		fmt.Println("parse env file:", err)
 		return 1
@@ -53,7 +56,7 @@ func upLocal(c *env.Config) int {
 		}
 
 		fmt.Printf("\n%s\nENVIRONMENT: %s\nLABEL: %s\n%s\n", strings.Repeat("=", 40), env.Name, env.Label, strings.Repeat("=", 40))
-		env.State("up")
+		env.State(ctx, "up")
 
 		return 0
 	case c.All:
@@ -65,7 +68,7 @@ func upLocal(c *env.Config) int {
 			}
 
 			fmt.Printf("\n%s\nENVIRONMENT: %s\nLABEL: %s\n%s\n", strings.Repeat("=", 40), env.Name, env.Label, strings.Repeat("=", 40))
-			env.State("up")
+			env.State(ctx, "up")
 			count++
 		}
 
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/marintailor/rcstate/cmd/api/env"
+)
+
+// envWatch restarts preempted instances of active environments until the process is stopped.
+func envWatch(args []string) int {
+	cfg := env.Config{}
+
+	if err := cfg.ParseFlags(args); err != nil {
+		fmt.Println("get config:", err)
+	}
+
+	if err := cfg.ParseEnvironmentFile(); err != nil {
+		fmt.Println("parse env file:", err)
+		return 1
+	}
+
+	if cfg.Name == "" && !cfg.All {
+		fmt.Println("watch: provide an environment name or --all")
+		return 1
+	}
+
+	fmt.Printf("watching preemptible instances every %s\n", cfg.Interval)
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	if err := cfg.Watch(ctx); err != nil {
+		fmt.Println("watch:", err)
+		return 1
+	}
+
+	return 0
+}
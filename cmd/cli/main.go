@@ -1,8 +1,12 @@
 package cli
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/marintailor/rcstate/cmd/server"
 )
@@ -22,6 +26,7 @@ func Run(args []string) int {
 	}
 
 	cmds := map[string]func([]string) int{
+		"dns": func(a []string) int { return dnsRun(a) },
 		"env": func(a []string) int { return envRun(a) },
 		"vm":  func(a []string) int { return vmRun(a) },
 	}
@@ -36,6 +41,19 @@ func Run(args []string) int {
 	return cmd(args[1:])
 }
 
+// signalContext returns a context that is canceled on interrupt or termination signal.
+// The signal handling is restored after the first signal, so a second one terminates the process.
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	return ctx, stop
+}
+
 // getConfig will get configuration from flags.
 func (c *config) getConfig(args []string) {
 	f := flag.NewFlagSet(args[0], flag.ContinueOnError)
@@ -54,6 +72,7 @@ func help() {
 Usage: rcstate <command> [options...]
 
 Commands:
+  dns     check DNS records of declared environments
   env     manage declared environments
   help    show usage information
   vm      manage state of virtual machine instance
@@ -12,10 +12,12 @@ func vmRun(args []string) int {
 	}
 
 	cmds := map[string]func([]string) int{
-		"list":   func(a []string) int { return vmList(a) },
-		"start":  func(a []string) int { return vmStart(a) },
-		"status": func(a []string) int { return vmStatus(a) },
-		"stop":   func(a []string) int { return vmStop(a) },
+		"list":    func(a []string) int { return vmList(a) },
+		"resume":  func(a []string) int { return vmResume(a) },
+		"start":   func(a []string) int { return vmStart(a) },
+		"status":  func(a []string) int { return vmStatus(a) },
+		"stop":    func(a []string) int { return vmStop(a) },
+		"suspend": func(a []string) int { return vmSuspend(a) },
 	}
 
 	cmd, ok := cmds[args[0]]
@@ -11,19 +11,40 @@ vm command usage:
 Commands:
   help      show usage information
   list      list virtual machines
+  resume    resume the suspended virtual machine
   start     start the virtual machine
   status    show status of the virtual machine
   stop      stop the virtual machine
+  suspend   suspend the virtual machine, keeping its memory state
 
 Options:
+  --all-zones          List instances from all zones of the project(s)
+
   -d, --domain         Domain for DNS record
 
+  --dns-endpoint       API endpoint of DNS provider "webhook", or a custom endpoint of provider "cloudflare"
+
+  --dns-provider       DNS provider of the record: route53 (default), clouddns, cloudflare, rfc2136, webhook
+                       provider "clouddns" uses the managed zones of option "project"
+                       provider "rfc2136" sends dynamic updates to option "dns-server"
+                       provider "webhook" sends JSON requests to option "dns-endpoint"
+
+  --dns-proxied        Proxy the traffic of the record through Cloudflare
+
   --dns-record-name    The DNS record name
 
   --dns-record-type    The DNS record type
 
+  --dns-server         Address of the DNS server that accepts dynamic updates (default port: 53)
+
+  --dns-token-file     File with the API token of DNS providers "cloudflare" and "webhook"
+                       default: environment variable CLOUDFLARE_API_TOKEN or RCSTATE_DNS_WEBHOOK_TOKEN
+
   --dry                Run the command without executing the logic
 
+  --endpoint           Custom API endpoint of the compute provider
+                       Connection URI for provider "libvirt" (default: qemu:///system)
+
   --external-ip        Use External IP address of instance for DNS record
 
   -f, --fprmat         Print the API request data of the command
@@ -34,9 +55,17 @@ Options:
   --ip                 Provide IP address for DNS record
                        Multiple addresses can be provided with comma delimiter
 
+  --machine-type       Machine type applied to the stopped instance before it is started
+
   -n, --name           Virtual Machine name
 
   -p, --project        Google Cloud Project ID
+                       Can be repeated for command "list"
+
+  --provider           Compute provider of the virtual machine
+                       Supported providers: gce (default), aws, libvirt
+
+  -r, --region         AWS region name, required by provider "aws"
 
   -s, --script         Run shell command(s) on virtual machine with SSH connection
                        NOTE: command(s) must be wrapped in double quotes
@@ -47,6 +76,16 @@ Options:
 
   --ssh-user           Username for SSH connection
 
+  --timeout            Timeout of the command
+                       default: 10m
+
+  --tsig-algorithm     TSIG algorithm of the dynamic updates: hmac-sha256 (default), hmac-sha1, hmac-sha512
+
+  --tsig-key           TSIG key name that signs the dynamic updates
+
+  --tsig-secret-file   File with the base64 TSIG secret of the key
+                       default: environment variable RCSTATE_TSIG_SECRET
+
   -z, --zone           Google Cloud Zone name
 
 Examples:
@@ -58,6 +97,14 @@ Examples:
       --zone <zone_name>
 
 
+  List all instances in all zones of multiple projects:
+
+    rcstate vm list \
+      --project <project_name> \
+      --project <project_name> \
+      --all-zones
+
+
   Start an instance in specific project and zone:
 
     rcstate vm start \
@@ -77,6 +124,14 @@ Examples:
       --dns-record-type <record_type>
 
 
+  Start an EC2 instance by Name tag or instance ID in specific region:
+
+    rcstate vm start \
+      --provider aws \
+      --name <instance_name_or_id> \
+      --region <region_name>
+
+
   Show status of an instance in specific project and zone:
 
     rcstate vm status \
@@ -93,6 +148,31 @@ Examples:
       --zone <zone_name>
 
 
+  Start an instance with a different machine type:
+
+    rcstate vm start \
+      --name <instance_name> \
+      --project <project_name> \
+      --zone <zone_name> \
+      --machine-type <machine_type>
+
+
+  Suspend an instance in specific project and zone:
+
+    rcstate vm suspend \
+      --name <instance_name> \
+      --project <project_name> \
+      --zone <zone_name>
+
+
+  Resume a suspended instance in specific project and zone:
+
+    rcstate vm resume \
+      --name <instance_name> \
+      --project <project_name> \
+      --zone <zone_name>
+
+
   Start an instance and run shell commands AFTER the instance is started:
 
     rcstate vm start \
@@ -26,13 +26,22 @@ func vmList(args []string) int {
 
 // listLocal returns the list by executing the logic locally.
 func listLocal(c *vm.Config) int {
-	vm, err := vm.NewVirtualMachine(c.Project, c.Zone)
+	parent, stop := signalContext()
+	defer stop()
+
+	ctx, cancel := c.Context(parent)
+	defer cancel()
+
+	vm, err := vm.NewVirtualMachine(c.ProviderOptions())
 	if err != nil {
 		fmt.Println("list: new environment:", err)
 		return 1
 	}
 
-	list := vm.List()
+	vm.AllZones = c.AllZones
+	vm.Projects = c.Projects
+
+	list := vm.List(ctx)
 	fmt.Println(list)
 
 	return 0
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	client "github.com/marintailor/rcstate/client/vm"
+	"github.com/marintailor/rcstate/cmd/api/vm"
+)
+
+// vmResume will resume a suspended instance.
+func vmResume(args []string) int {
+	cfg := vm.Config{}
+
+	if err := cfg.ParseFlags(args); err != nil {
+		fmt.Println("get config:", err)
+	}
+
+	if cfg.Name == "" {
+		fmt.Println("Please provide the instance's name.")
+		return 1
+	}
+
+	if cfg.Host != "" {
+		return resumeRemote(&cfg)
+	}
+
+	return resumeLocal(&cfg)
+
+}
+
+// resumeLocal will resume an instance by executing the logic locally.
+func resumeLocal(c *vm.Config) int {
+	parent, stop := signalContext()
+	defer stop()
+
+	ctx, cancel := c.Context(parent)
+	defer cancel()
+
+	v, err := vm.NewVirtualMachine(c.ProviderOptions())
+	if err != nil {
+		fmt.Println("resume: new environment:", err)
+		return 1
+	}
+
+	if err := v.Resume(ctx, c.Name); err != nil {
+		fmt.Println("resume: ", err)
+		return 1
+	}
+
+	if c.DNS.RecordName != "" {
+		dnsRecord := fmt.Sprintf("%s.%s", c.DNS.RecordName, c.DNS.Domain)
+		c.Record(ctx, dnsRecord)
+	}
+
+	if c.Script.CMD != "" {
+		c.ExecuteScript(ctx)
+	}
+
+	return 0
+}
+
+// resumeRemote will resume an instance by sending a request to remote server.
+func resumeRemote(c *vm.Config) int {
+	j, err := json.Marshal(c)
+	if err != nil {
+		fmt.Println("marshal config:", err)
+		return 1
+	}
+
+	if c.Format == "json" {
+		fmt.Println(string(j))
+	}
+
+	if !c.Dry {
+		_, err = client.Resume(string(j), c.Host)
+		if err != nil {
+			fmt.Println("client vm resume:", err)
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -31,23 +31,36 @@ func vmStart(args []string) int {
 
 // startLocal will start an instance by executing the logic locally.
 func startLocal(c *vm.Config) int {
-	v, err := vm.NewVirtualMachine(c.Project, c.Zone)
+	parent, stop := signalContext()
+	defer stop()
+
+	ctx, cancel := c.Context(parent)
+	defer cancel()
+
+	v, err := vm.NewVirtualMachine(c.ProviderOptions())
 	if err != nil {
 		fmt.Println("start: new environment:", err)
 		return 1
 	}
 
-	if err := v.Start(c.Name); err != nil {
+	if c.MachineType != "" {
+		if err := v.SetMachineType(ctx, c.Name, c.MachineType); err != nil {
+			fmt.Println("start: set machine type:", err)
+			return 1
+		}
+	}
+
+	if err := v.Start(ctx, c.Name); err != nil {
 		fmt.Println("start: ", err)
 	}
 
 	if c.DNS.RecordName != "" {
 		dnsRecord := fmt.Sprintf("%s.%s", c.DNS.RecordName, c.DNS.Domain)
-		c.Record(dnsRecord)
+		c.Record(ctx, dnsRecord)
 	}
 
 	if c.Script.CMD != "" {
-		c.ExecuteScript()
+		c.ExecuteScript(ctx)
 	}
 
 	return 0
@@ -31,13 +31,19 @@ func vmStatus(args []string) int {
 
 // statusLocal return the status of the instance by executing the logic locally.
 func statusLocal(c *vm.Config) int {
-	v, err := vm.NewVirtualMachine(c.Project, c.Zone)
+	parent, stop := signalContext()
+	defer stop()
+
+	ctx, cancel := c.Context(parent)
+	defer cancel()
+
+	v, err := vm.NewVirtualMachine(c.ProviderOptions())
 	if err != nil {
 		fmt.Println("status: new environment:", err)
 		return 1
 	}
 
-	status, err := v.Status(c.Name)
+	status, err := v.Status(ctx, c.Name)
 	if err != nil {
 		fmt.Println("status: ", err)
 	}
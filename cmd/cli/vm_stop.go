@@ -31,23 +31,29 @@ func vmStop(args []string) int {
 
 // stopLocal will stop an instance by executing the logic locally.
 func stopLocal(c *vm.Config) int {
-	v, err := vm.NewVirtualMachine(c.Project, c.Zone)
+	parent, stop := signalContext()
+	defer stop()
+
+	ctx, cancel := c.Context(parent)
+	defer cancel()
+
+	v, err := vm.NewVirtualMachine(c.ProviderOptions())
 	if err != nil {
 		fmt.Println("stop: new environment:", err)
 		return 1
 	}
 
-	if err := v.Stop(c.Name); err != nil {
+	if err := v.Stop(ctx, c.Name); err != nil {
 		fmt.Println("stop: ", err)
 	}
 
 	if c.DNS.RecordName != "" {
 		dnsRecord := fmt.Sprintf("%s.%s", c.DNS.RecordName, c.DNS.Domain)
-		c.Record(dnsRecord)
+		c.Record(ctx, dnsRecord)
 	}
 
 	if c.Script.CMD != "" {
-		c.ExecuteScript()
+		c.ExecuteScript(ctx)
 	}
 
 	return 0
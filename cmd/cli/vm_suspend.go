@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	client "github.com/marintailor/rcstate/client/vm"
+	"github.com/marintailor/rcstate/cmd/api/vm"
+)
+
+// vmSuspend will suspend an instance.
+func vmSuspend(args []string) int {
+	cfg := vm.Config{}
+
+	if err := cfg.ParseFlags(args); err != nil {
+		fmt.Println("get config:", err)
+	}
+
+	if cfg.Name == "" {
+		fmt.Println("Please provide the instance's name.")
+		return 1
+	}
+
+	if cfg.Host != "" {
+		return suspendRemote(&cfg)
+	}
+
+	return suspendLocal(&cfg)
+
+}
+
+// suspendLocal will suspend an instance by executing the logic locally.
+func suspendLocal(c *vm.Config) int {
+	parent, stop := signalContext()
+	defer stop()
+
+	ctx, cancel := c.Context(parent)
+	defer cancel()
+
+	v, err := vm.NewVirtualMachine(c.ProviderOptions())
+	if err != nil {
+		fmt.Println("suspend: new environment:", err)
+		return 1
+	}
+
+	if c.Script.CMD != "" {
+		c.ExecuteScript(ctx)
+	}
+
+	if err := v.Suspend(ctx, c.Name); err != nil {
+		fmt.Println("suspend: ", err)
+		return 1
+	}
+
+	return 0
+}
+
+// suspendRemote will suspend an instance by sending a request to remote server.
+func suspendRemote(c *vm.Config) int {
+	j, err := json.Marshal(c)
+	if err != nil {
+		fmt.Println("marshal config:", err)
+		return 1
+	}
+
+	if c.Format == "json" {
+		fmt.Println(string(j))
+	}
+
+	if !c.Dry {
+		_, err = client.Suspend(string(j), c.Host)
+		if err != nil {
+			fmt.Println("client vm suspend:", err)
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -25,7 +25,7 @@ func Down(w http.ResponseWriter, r *http.Request) {
 			log.Println("get config:", err)
 		}
 
-		json, err := cfg.Down()
+		json, err := cfg.Down(r.Context())
 		if err != nil {
 			msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
 			w.WriteHeader(http.StatusInternalServerError)
@@ -62,7 +62,7 @@ func Show(w http.ResponseWriter, r *http.Request) {
 			log.Println("get config:", err)
 		}
 
-		json, err := cfg.Show()
+		json, err := cfg.Show(r.Context())
 		if err != nil {
 			msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
 			w.WriteHeader(http.StatusInternalServerError)
@@ -108,7 +108,7 @@ func Up(w http.ResponseWriter, r *http.Request) {
 			log.Println("get config:", err)
 		}
 
-		json, err := cfg.Up()
+		json, err := cfg.Up(r.Context())
 		if err != nil {
 			msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
 			w.WriteHeader(http.StatusInternalServerError)
@@ -25,16 +25,23 @@ func List(w http.ResponseWriter, r *http.Request) {
 			log.Println("get config:", err)
 		}
 
-		vm, err := vm.NewVirtualMachine(cfg.Project, cfg.Zone)
+		vm, err := vm.NewVirtualMachine(cfg.ProviderOptions())
 		if err != nil {
 			msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			if _, err := w.Write([]byte(msg)); err != nil {
 				log.Printf("write to response: %v", err)
 			}
+			return
 		}
 
-		list := vm.List()
+		ctx, cancel := cfg.Context(r.Context())
+		defer cancel()
+
+		vm.AllZones = cfg.AllZones
+		vm.Projects = cfg.Projects
+
+		list := vm.List(ctx)
 		w.WriteHeader(http.StatusOK)
 		if _, err := w.Write([]byte(list)); err != nil {
 			log.Printf("write to response: %v", err)
@@ -63,16 +70,31 @@ func Start(w http.ResponseWriter, r *http.Request) {
 			log.Println("get config:", err)
 		}
 
-		vm, err := vm.NewVirtualMachine(cfg.Project, cfg.Zone)
+		vm, err := vm.NewVirtualMachine(cfg.ProviderOptions())
 		if err != nil {
 			msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			if _, err := w.Write([]byte(msg)); err != nil {
 				log.Printf("write to response: %v", err)
 			}
+			return
 		}
 
-		if err := vm.Start(cfg.Name); err != nil {
+		ctx, cancel := cfg.Context(r.Context())
+		defer cancel()
+
+		if cfg.MachineType != "" {
+			if err := vm.SetMachineType(ctx, cfg.Name, cfg.MachineType); err != nil {
+				msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				if _, err := w.Write([]byte(msg)); err != nil {
+					log.Printf("write to response: %v", err)
+				}
+				return
+			}
+		}
+
+		if err := vm.Start(ctx, cfg.Name); err != nil {
 			msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			if _, err := w.Write([]byte(msg)); err != nil {
@@ -82,11 +104,11 @@ func Start(w http.ResponseWriter, r *http.Request) {
 
 		if cfg.DNS.RecordName != "" {
 			dnsRecord := fmt.Sprintf("%s.%s", cfg.DNS.RecordName, cfg.DNS.Domain)
-			cfg.Record(dnsRecord)
+			cfg.Record(ctx, dnsRecord)
 		}
 
 		if cfg.Script.CMD != "" {
-			cfg.ExecuteScript()
+			cfg.ExecuteScript(ctx)
 		}
 
 		w.WriteHeader(http.StatusOK)
@@ -118,16 +140,20 @@ func Status(w http.ResponseWriter, r *http.Request) {
 			log.Println("get config:", err)
 		}
 
-		vm, err := vm.NewVirtualMachine(cfg.Project, cfg.Zone)
+		vm, err := vm.NewVirtualMachine(cfg.ProviderOptions())
 		if err != nil {
 			msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			if _, err := w.Write([]byte(msg)); err != nil {
 				log.Printf("write to response: %v", err)
 			}
+			return
 		}
 
-		status, err := vm.Status(cfg.Name)
+		ctx, cancel := cfg.Context(r.Context())
+		defer cancel()
+
+		status, err := vm.Status(ctx, cfg.Name)
 		if err != nil {
 			msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
 			w.WriteHeader(http.StatusInternalServerError)
@@ -149,6 +175,66 @@ func Status(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Resume is a handler function to resume a suspended virtual machine.
+func Resume(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodPost:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Println("read body:", err)
+		}
+
+		cfg := vm.Config{}
+		if err := cfg.GetConfig(body); err != nil {
+			log.Println("get config:", err)
+		}
+
+		vm, err := vm.NewVirtualMachine(cfg.ProviderOptions())
+		if err != nil {
+			msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			if _, err := w.Write([]byte(msg)); err != nil {
+				log.Printf("write to response: %v", err)
+			}
+			return
+		}
+
+		ctx, cancel := cfg.Context(r.Context())
+		defer cancel()
+
+		if err := vm.Resume(ctx, cfg.Name); err != nil {
+			msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			if _, err := w.Write([]byte(msg)); err != nil {
+				log.Printf("write to response: %v", err)
+			}
+			return
+		}
+
+		if cfg.DNS.RecordName != "" {
+			dnsRecord := fmt.Sprintf("%s.%s", cfg.DNS.RecordName, cfg.DNS.Domain)
+			cfg.Record(ctx, dnsRecord)
+		}
+
+		if cfg.Script.CMD != "" {
+			cfg.ExecuteScript(ctx)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		msg := "{ \"status\": \"success\"}"
+		if _, err := w.Write([]byte(msg)); err != nil {
+			log.Printf("write to response: %v", err)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		if _, err := w.Write([]byte("{\"error\":\"method not allowed\"}")); err != nil {
+			log.Printf("could not write to response: %v", err)
+		}
+	}
+}
+
 // Stop is a handler function to stop a virtual machine.
 func Stop(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -165,21 +251,81 @@ func Stop(w http.ResponseWriter, r *http.Request) {
 			log.Println("get config:", err)
 		}
 
-		vm, err := vm.NewVirtualMachine(cfg.Project, cfg.Zone)
+		vm, err := vm.NewVirtualMachine(cfg.ProviderOptions())
+		if err != nil {
+			msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			if _, err := w.Write([]byte(msg)); err != nil {
+				log.Printf("write to response: %v", err)
+			}
+			return
+		}
+
+		ctx, cancel := cfg.Context(r.Context())
+		defer cancel()
+
+		if err := vm.Stop(ctx, cfg.Name); err != nil {
+			msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			if _, err := w.Write([]byte(msg)); err != nil {
+				log.Printf("write to response: %v", err)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		msg := "{ \"status\": \"success\"}"
+		if _, err := w.Write([]byte(msg)); err != nil {
+			log.Printf("write to response: %v", err)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		if _, err := w.Write([]byte("{\"error\":\"method not allowed\"}")); err != nil {
+			log.Printf("could not write to response: %v", err)
+		}
+	}
+}
+
+// Suspend is a handler function to suspend a virtual machine.
+func Suspend(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodPost:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Println("read body:", err)
+		}
+
+		cfg := vm.Config{}
+		if err := cfg.GetConfig(body); err != nil {
+			log.Println("get config:", err)
+		}
+
+		vm, err := vm.NewVirtualMachine(cfg.ProviderOptions())
 		if err != nil {
 			msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			if _, err := w.Write([]byte(msg)); err != nil {
 				log.Printf("write to response: %v", err)
 			}
+			return
+		}
+
+		ctx, cancel := cfg.Context(r.Context())
+		defer cancel()
+
+		if cfg.Script.CMD != "" {
+			cfg.ExecuteScript(ctx)
 		}
 
-		if err := vm.Stop(cfg.Name); err != nil {
+		if err := vm.Suspend(ctx, cfg.Name); err != nil {
 			msg := fmt.Sprintf("{ \"error\": \"%s\"}", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			if _, err := w.Write([]byte(msg)); err != nil {
 				log.Printf("write to response: %v", err)
 			}
+			return
 		}
 
 		w.WriteHeader(http.StatusOK)
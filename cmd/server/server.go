@@ -25,9 +25,11 @@ func NewServer(port string) {
 	router.HandleFunc("/v1/env/up", env.Up)
 
 	router.HandleFunc("/v1/vm/list", vm.List)
+	router.HandleFunc("/v1/vm/resume", vm.Resume)
 	router.HandleFunc("/v1/vm/start", vm.Start)
 	router.HandleFunc("/v1/vm/status", vm.Status)
 	router.HandleFunc("/v1/vm/stop", vm.Stop)
+	router.HandleFunc("/v1/vm/suspend", vm.Suspend)
 
 	log.Println("Listening on localhost:8080")
 